@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+// defaultBatchFormat is the output format of a batch conversion without --format.
+const defaultBatchFormat = "srt"
+
+// isBatchInput reports whether the input names a directory or a glob pattern
+// rather than a single file.
+func isBatchInput(input string) bool {
+	if info, err := os.Stat(input); err == nil {
+		return info.IsDir()
+	}
+	return hasGlobMeta(input)
+}
+
+// hasGlobMeta reports whether path contains any filepath.Match metacharacters.
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// collectBatchInputs expands a directory or glob pattern into a sorted list
+// of input files with a readable extension. It also returns the base
+// directory the files are relative to, which is mirrored into the output
+// directory.
+func collectBatchInputs(input string, recursive bool) (string, []string, error) {
+	info, err := os.Stat(input)
+	if err == nil && info.IsDir() {
+		files, err := walkDirectory(input, recursive)
+		return input, files, err
+	}
+
+	matches, err := filepath.Glob(input)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid glob pattern %s: %w", input, err)
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() && isDecodable(match) {
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+
+	return globBase(input), files, nil
+}
+
+// walkDirectory lists the files in dir whose extension belongs to a format
+// that can be read, descending into subdirectories when recursive is set.
+func walkDirectory(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isDecodable(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+// isDecodable reports whether the extension of path belongs to a format that
+// can be read.
+func isDecodable(path string) bool {
+	format, ok := sbv.LookupExtension(filepath.Ext(path))
+	return ok && format.CanDecode()
+}
+
+// globBase returns the directory part of a glob pattern that precedes the
+// first metacharacter.
+func globBase(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for hasGlobMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// planBatch builds the list of conversions for a batch input. Outputs are
+// written next to their inputs, or into outputDir mirroring the source tree.
+// Files that would be written over themselves are left out. Jobs whose output
+// would also be written by another job, such as a.sbv and a.ass both
+// becoming a.srt, or would replace another input, are returned with the
+// error they fail with in conflicts; they must not be started.
+func planBatch(input, outputDir, format string, recursive bool) (jobs []sbv.BatchJob, conflicts map[sbv.BatchJob]error, err error) {
+	if format == "" {
+		format = defaultBatchFormat
+	}
+	outFormat, err := resolveOutputFormat("", format)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(outFormat.Extensions) == 0 {
+		return nil, nil, fmt.Errorf("format %s has no file extension and cannot be used for batch conversion", outFormat.Name)
+	}
+
+	if outputDir != "" {
+		if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+			return nil, nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+		}
+	}
+
+	base, files, err := collectBatchInputs(input, recursive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot mirror %s into the output directory: %w", file, err)
+		}
+		target := strings.TrimSuffix(rel, filepath.Ext(rel)) + outFormat.Extensions[0]
+		if outputDir != "" {
+			target = filepath.Join(outputDir, target)
+		} else {
+			target = filepath.Join(base, target)
+		}
+
+		// Files already in the output format would overwrite themselves
+		if strings.EqualFold(filepath.Clean(target), filepath.Clean(file)) {
+			continue
+		}
+
+		jobs = append(jobs, sbv.BatchJob{Input: file, Output: target})
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil, fmt.Errorf("no subtitle files to convert found in %s", input)
+	}
+
+	return jobs, batchConflicts(jobs), nil
+}
+
+// batchConflicts finds the jobs whose output is also the output or the input
+// of another job. Files left out because they are already in the output
+// format do not count, so a batch can be run again in place. Paths are
+// compared case-insensitively, as they name the same file on
+// case-insensitive file systems.
+func batchConflicts(jobs []sbv.BatchJob) map[sbv.BatchJob]error {
+	key := func(path string) string {
+		return strings.ToLower(filepath.Clean(path))
+	}
+	writers := make(map[string][]string)
+	inputs := make(map[string]bool)
+	for _, job := range jobs {
+		writers[key(job.Output)] = append(writers[key(job.Output)], job.Input)
+		inputs[key(job.Input)] = true
+	}
+
+	conflicts := make(map[sbv.BatchJob]error)
+	for _, job := range jobs {
+		switch {
+		case len(writers[key(job.Output)]) > 1:
+			conflicts[job] = fmt.Errorf("output file %s would be written by %d inputs: %s",
+				job.Output, len(writers[key(job.Output)]), strings.Join(writers[key(job.Output)], ", "))
+		case inputs[key(job.Output)]:
+			conflicts[job] = fmt.Errorf("output file %s is also an input of the batch", job.Output)
+		}
+	}
+	return conflicts
+}
+
+// errSkipped marks a batch job left alone with --no-clobber because its
+// output file already exists.
+var errSkipped = errors.New("output file already exists")
+
+// convertBatch converts every file matched by the input directory or glob on
+// --jobs workers. Failures are reported per file, in input order, and do not
+// stop the remaining conversions. Cancelling ctx stops starting new files.
+func convertBatch(ctx context.Context, transform transformFunc) error {
+	if outputFile != "" {
+		return fmt.Errorf("--output cannot be used with a directory or glob input, use --output-dir instead")
+	}
+
+	jobs, conflicts, err := planBatch(inputFile, outputDir, outputFormat, recursive)
+	if err != nil {
+		return fmt.Errorf("batch planning failed: %w", err)
+	}
+
+	logf("Converting %d files from: %s\n", len(jobs), inputFile)
+
+	var runnable []sbv.BatchJob
+	for _, job := range jobs {
+		if conflicts[job] == nil {
+			runnable = append(runnable, job)
+		}
+	}
+	converted := sbv.ConvertBatch(ctx, runnable, parallelJobs, func(ctx context.Context, job sbv.BatchJob) (int, []sbv.Diagnostic, error) {
+		return convertBatchJob(ctx, job, transform)
+	})
+
+	// Conflicting jobs fail in their place in the input order
+	results := make([]sbv.BatchResult, 0, len(jobs))
+	for _, job := range jobs {
+		if err := conflicts[job]; err != nil {
+			results = append(results, sbv.BatchResult{BatchJob: job, Err: err})
+			continue
+		}
+		results = append(results, converted[0])
+		converted = converted[1:]
+	}
+	for _, result := range results {
+		printDiagnostics(os.Stderr, result.Input, result.Diagnostics)
+	}
+
+	return reportBatch(progressOutput(), os.Stderr, results)
+}
+
+// convertBatchJob converts a single file of a batch, applying transform if it
+// is not nil and creating the mirrored output directory if needed.
+// Diagnostics are returned rather than printed so that output from
+// concurrent jobs does not interleave.
+func convertBatchJob(ctx context.Context, job sbv.BatchJob, transform transformFunc) (int, []sbv.Diagnostic, error) {
+	if err := validateInputFile(job.Input); err != nil {
+		return 0, nil, err
+	}
+
+	inFormat, err := resolveInputFormat(job.Input, inputFormat)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.Output), 0o755); err != nil {
+		return 0, nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	outputPath, err := determineOutputPath(job.Input, job.Output, outputFormat)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	outFormat, err := resolveOutputFormat(outputPath, outputFormat)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	skip, err := checkOutputPath(job.Input, outputPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	if skip {
+		return 0, nil, errSkipped
+	}
+
+	subtitles, diagnostics, err := decodeFile(job.Input, inFormat, lenient)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse %s file: %w", strings.ToUpper(inFormat.Name), err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, diagnostics, err
+	}
+
+	if subtitles, err = prepareSubtitles(subtitles, transform); err != nil {
+		return 0, diagnostics, err
+	}
+
+	encoder, err := outputEncoder(outFormat)
+	if err != nil {
+		return 0, diagnostics, err
+	}
+	if err := encodeFile(outputPath, encoder, subtitles); err != nil {
+		return 0, diagnostics, fmt.Errorf("failed to write %s file: %w", strings.ToUpper(outFormat.Name), err)
+	}
+
+	return len(subtitles), diagnostics, nil
+}
+
+// reportBatch prints one line per conversion and a summary. Failures go to
+// errW so they are shown even when progress output is suppressed. It returns
+// an error if any conversion failed, so the command exits with a non-zero code.
+func reportBatch(w, errW io.Writer, results []sbv.BatchResult) error {
+	failed, skipped := 0, 0
+	for _, result := range results {
+		switch {
+		case errors.Is(result.Err, errSkipped):
+			skipped++
+			fmt.Fprintf(w, "SKIP %s: %s already exists\n", result.Input, result.Output)
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(errW, "FAIL %s: %v\n", result.Input, result.Err)
+		default:
+			fmt.Fprintf(w, "OK   %s -> %s (%d subtitles)\n", result.Input, result.Output, result.Count)
+		}
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(w, "Converted %d of %d files, %d skipped, %d failed\n", len(results)-failed-skipped, len(results), skipped, failed)
+	} else {
+		fmt.Fprintf(w, "Converted %d of %d files, %d failed\n", len(results)-failed, len(results), failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to convert", failed, len(results))
+	}
+	return nil
+}
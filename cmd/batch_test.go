@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+// makeTree creates files with the given relative paths and content under root.
+func makeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestIsBatchInput(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.sbv")
+	makeTree(t, dir, map[string]string{"a.sbv": ""})
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: dir, want: true},
+		{input: file, want: false},
+		{input: filepath.Join(dir, "*.sbv"), want: true},
+		{input: filepath.Join(dir, "missing.sbv"), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isBatchInput(tt.input); got != tt.want {
+			t.Errorf("isBatchInput(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "archive/*.sbv", want: "archive"},
+		{pattern: "archive/*/captions/*.sbv", want: "archive"},
+		{pattern: "*.sbv", want: "."},
+		{pattern: "/data/2024-0[1-6]/*.sbv", want: "/data"},
+	}
+
+	for _, tt := range tests {
+		if got := globBase(tt.pattern); got != tt.want {
+			t.Errorf("globBase(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatch(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	makeTree(t, src, map[string]string{
+		"a.sbv":             "",
+		"b.srt":             "",
+		"notes.txt":         "",
+		"2024/jan/c.sbv":    "",
+		"2024/feb/d.SBV":    "",
+		"2024/feb/done.srt": "",
+	})
+	clash := t.TempDir()
+	makeTree(t, clash, map[string]string{
+		"a.sbv": "",
+		"a.ass": "",
+		"b.srt": "",
+		"b.sbv": "",
+	})
+
+	tests := []struct {
+		name      string
+		input     string
+		outputDir string
+		format    string
+		recursive bool
+		want      []sbv.BatchJob
+		conflicts []string
+		wantErr   bool
+		errMsg    string
+	}{
+		{
+			name:  "directory next to inputs",
+			input: src,
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(src, "a.sbv"), Output: filepath.Join(src, "a.srt")},
+			},
+		},
+		{
+			name:      "recursive directory mirrored into output directory",
+			input:     src,
+			outputDir: out,
+			recursive: true,
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(src, "2024/feb/d.SBV"), Output: filepath.Join(out, "2024/feb/d.srt")},
+				{Input: filepath.Join(src, "2024/feb/done.srt"), Output: filepath.Join(out, "2024/feb/done.srt")},
+				{Input: filepath.Join(src, "2024/jan/c.sbv"), Output: filepath.Join(out, "2024/jan/c.srt")},
+				{Input: filepath.Join(src, "a.sbv"), Output: filepath.Join(out, "a.srt")},
+				{Input: filepath.Join(src, "b.srt"), Output: filepath.Join(out, "b.srt")},
+			},
+		},
+		{
+			name:   "explicit format skips files that would overwrite themselves",
+			input:  src,
+			format: "sbv",
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(src, "b.srt"), Output: filepath.Join(src, "b.sbv")},
+			},
+		},
+		{
+			name:      "glob mirrors relative to the pattern base",
+			input:     filepath.Join(src, "2024", "*", "*.sbv"),
+			outputDir: out,
+			format:    "vtt",
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(src, "2024/jan/c.sbv"), Output: filepath.Join(out, "jan/c.vtt")},
+			},
+		},
+		{
+			name:  "colliding outputs are conflicts",
+			input: clash,
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(clash, "a.ass"), Output: filepath.Join(clash, "a.srt")},
+				{Input: filepath.Join(clash, "a.sbv"), Output: filepath.Join(clash, "a.srt")},
+				{Input: filepath.Join(clash, "b.sbv"), Output: filepath.Join(clash, "b.srt")},
+			},
+			conflicts: []string{filepath.Join(clash, "a.ass"), filepath.Join(clash, "a.sbv")},
+		},
+		{
+			name:   "existing files in the output format are not conflicts",
+			input:  clash,
+			format: "sbv",
+			want: []sbv.BatchJob{
+				{Input: filepath.Join(clash, "a.ass"), Output: filepath.Join(clash, "a.sbv")},
+				{Input: filepath.Join(clash, "b.srt"), Output: filepath.Join(clash, "b.sbv")},
+			},
+		},
+		{
+			name:    "nothing to convert",
+			input:   filepath.Join(src, "*.txt"),
+			wantErr: true,
+			errMsg:  "no subtitle files to convert",
+		},
+		{
+			name:      "missing output directory",
+			input:     src,
+			outputDir: filepath.Join(out, "missing"),
+			wantErr:   true,
+			errMsg:    "output directory does not exist",
+		},
+		{
+			name:    "unsupported format",
+			input:   src,
+			format:  "xyz",
+			wantErr: true,
+			errMsg:  "unsupported output format: xyz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts, err := planBatch(tt.input, tt.outputDir, tt.format, tt.recursive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("planBatch() error = %v, want error containing %v", err, tt.errMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planBatch() = %+v, want %+v", got, tt.want)
+			}
+			var gotConflicts []string
+			for _, job := range got {
+				if conflicts[job] != nil {
+					gotConflicts = append(gotConflicts, job.Input)
+				}
+			}
+			if !reflect.DeepEqual(gotConflicts, tt.conflicts) {
+				t.Errorf("planBatch() conflicts = %v, want %v", gotConflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestConvertBatchTwiceInPlace(t *testing.T) {
+	savedInput, savedOutput, savedDir, savedFormat := inputFile, outputFile, outputDir, outputFormat
+	savedForce, savedNoClobber, savedQuiet := force, noClobber, quiet
+	defer func() {
+		inputFile, outputFile, outputDir, outputFormat = savedInput, savedOutput, savedDir, savedFormat
+		force, noClobber, quiet = savedForce, savedNoClobber, savedQuiet
+	}()
+
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{"a.sbv": "0:00:01.000,0:00:02.000\nHello\n"})
+	inputFile, outputFile, outputDir, outputFormat, quiet = dir, "", "", "", true
+
+	// The a.srt written by the first run is left out of the second, not a conflict
+	for _, mode := range []struct {
+		name             string
+		force, noClobber bool
+	}{
+		{name: "first run"},
+		{name: "again with --force", force: true},
+		{name: "again with --no-clobber", noClobber: true},
+	} {
+		force, noClobber = mode.force, mode.noClobber
+		if err := convertBatch(context.Background(), nil); err != nil {
+			t.Errorf("convertBatch() %s unexpected error: %v", mode.name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "a.srt"))
+	if err != nil || string(content) != "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n" {
+		t.Errorf("convertBatch() wrote %q, %v", content, err)
+	}
+}
+
+func TestConvertBatchJob(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+	makeTree(t, src, map[string]string{
+		"good.sbv": "0:00:01.000,0:00:02.000\nHello\n",
+		"bad.sbv":  "0:00:01.000,0:xx:02.000\nHello\n",
+	})
+
+	job := sbv.BatchJob{Input: filepath.Join(src, "good.sbv"), Output: filepath.Join(out, "nested", "good.srt")}
+	count, _, err := convertBatchJob(context.Background(), job, nil)
+	if err != nil {
+		t.Fatalf("convertBatchJob() unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("convertBatchJob() count = %d, want 1", count)
+	}
+	content, err := os.ReadFile(job.Output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n" {
+		t.Errorf("convertBatchJob() wrote %q", string(content))
+	}
+
+	_, _, err = convertBatchJob(context.Background(), sbv.BatchJob{Input: filepath.Join(src, "bad.sbv"), Output: filepath.Join(out, "bad.srt")}, nil)
+	if err == nil {
+		t.Errorf("convertBatchJob() expected error for malformed input, got nil")
+	}
+}
+
+func TestConvertBatchJobTransform(t *testing.T) {
+	src := t.TempDir()
+	makeTree(t, src, map[string]string{"a.sbv": "0:00:01.000,0:00:02.000\nHello\n"})
+
+	shift := func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+		return sbv.Shift(subtitles, time.Second), nil
+	}
+	job := sbv.BatchJob{Input: filepath.Join(src, "a.sbv"), Output: filepath.Join(src, "a.srt")}
+	if _, _, err := convertBatchJob(context.Background(), job, shift); err != nil {
+		t.Fatalf("convertBatchJob() unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(job.Output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(content) != "1\n00:00:02,000 --> 00:00:03,000\nHello\n\n" {
+		t.Errorf("convertBatchJob() wrote %q, want shifted cue", string(content))
+	}
+}
+
+func TestConvertBatchJobCancelled(t *testing.T) {
+	src := t.TempDir()
+	makeTree(t, src, map[string]string{"a.sbv": "0:00:01.000,0:00:02.000\nHello\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	job := sbv.BatchJob{Input: filepath.Join(src, "a.sbv"), Output: filepath.Join(src, "a.srt")}
+	if _, _, err := convertBatchJob(ctx, job, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("convertBatchJob() error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(job.Output); !os.IsNotExist(err) {
+		t.Errorf("convertBatchJob() wrote %s after cancellation", job.Output)
+	}
+}
+
+func TestReportBatch(t *testing.T) {
+	results := []sbv.BatchResult{
+		{BatchJob: sbv.BatchJob{Input: "a.sbv", Output: "a.srt"}, Count: 3},
+		{BatchJob: sbv.BatchJob{Input: "b.sbv", Output: "b.srt"}, Err: errors.New("boom")},
+	}
+
+	var buf, errBuf bytes.Buffer
+	err := reportBatch(&buf, &errBuf, results)
+	if err == nil || !contains(err.Error(), "1 of 2 files failed") {
+		t.Errorf("reportBatch() error = %v, want failure summary", err)
+	}
+
+	want := "OK   a.sbv -> a.srt (3 subtitles)\n" +
+		"Converted 1 of 2 files, 1 failed\n"
+	if buf.String() != want {
+		t.Errorf("reportBatch() wrote %q, want %q", buf.String(), want)
+	}
+	if errBuf.String() != "FAIL b.sbv: boom\n" {
+		t.Errorf("reportBatch() wrote failures %q, want %q", errBuf.String(), "FAIL b.sbv: boom\n")
+	}
+
+	buf.Reset()
+	if err := reportBatch(&buf, &errBuf, results[:1]); err != nil {
+		t.Errorf("reportBatch() unexpected error: %v", err)
+	}
+}
+
+func TestConvertBatchJobExistingOutput(t *testing.T) {
+	savedForce, savedNoClobber := force, noClobber
+	defer func() { force, noClobber = savedForce, savedNoClobber }()
+
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"a.sbv": "0:00:01.000,0:00:02.000\nHello\n",
+		"a.srt": "existing",
+	})
+	job := sbv.BatchJob{Input: filepath.Join(dir, "a.sbv"), Output: filepath.Join(dir, "a.srt")}
+
+	force, noClobber = false, false
+	if _, _, err := convertBatchJob(context.Background(), job, nil); !errors.Is(err, errOutputExists) {
+		t.Errorf("convertBatchJob() error = %v, want errOutputExists", err)
+	}
+
+	noClobber = true
+	if _, _, err := convertBatchJob(context.Background(), job, nil); !errors.Is(err, errSkipped) {
+		t.Errorf("convertBatchJob() with --no-clobber error = %v, want errSkipped", err)
+	}
+	if content, _ := os.ReadFile(job.Output); string(content) != "existing" {
+		t.Errorf("convertBatchJob() changed a file it should have left alone: %q", content)
+	}
+
+	var buf, errBuf bytes.Buffer
+	results := []sbv.BatchResult{{BatchJob: job, Err: errSkipped}}
+	if err := reportBatch(&buf, &errBuf, results); err != nil {
+		t.Errorf("reportBatch() unexpected error for a skipped file: %v", err)
+	}
+	want := "SKIP " + job.Input + ": " + job.Output + " already exists\n" +
+		"Converted 0 of 1 files, 1 skipped, 0 failed\n"
+	if buf.String() != want {
+		t.Errorf("reportBatch() wrote %q, want %q", buf.String(), want)
+	}
+
+	force, noClobber = true, false
+	if count, _, err := convertBatchJob(context.Background(), job, nil); err != nil || count != 1 {
+		t.Errorf("convertBatchJob() with --force = %d, %v, want 1 subtitle written", count, err)
+	}
+}
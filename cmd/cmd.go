@@ -2,34 +2,86 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
 )
 
+// stdio is the path that stands for standard input or standard output.
+const stdio = "-"
+
 var (
-	inputFile  string
-	outputFile string
-	version    string
+	inputFile      string
+	outputFile     string
+	inputFormat    string
+	outputFormat   string
+	vttCueIDs      bool
+	vttCueSettings string
+	assStyleFile   string
+	assStripTags   bool
+	ttmlLanguage   string
+	youtubeWords   bool
+	lenient        bool
+	outputDir      string
+	recursive      bool
+	parallelJobs   int
+	quiet          bool
+	force          bool
+	noClobber      bool
+	sortCues       bool
+	overlapMode    string
+	minGap         time.Duration
+	mergeShort     time.Duration
+	mergeGap       time.Duration
+	mergeMax       time.Duration
+	splitLong      time.Duration
+	inputEncoding  string
+	outputEncoding string
+	lineEnding     string
+	preserveSpace  bool
+	startIndex     int
+	srtSeparator   string
+	sbvHourDigits  int
+	wrapWidth      int
+	wrapLines      int
+	wrapBalance    bool
+	wrapSplit      bool
+	version        string
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "go-sbv-to-srt",
-	Short: "Convert SBV subtitle files to SRT format",
+	Short: "Convert SBV subtitle files to SRT format and back",
 	Long: `A CLI tool to convert SBV (SubViewer) subtitle files to SRT (SubRip) format.
 		SBV files are commonly used by YouTube and other platforms, while SRT is a more
 		widely supported subtitle format that can be used across various media players
 		and video editing software.
 
+		The conversion direction is picked from the file extensions, so an edited
+		SRT file can be turned back into SBV for uploading to YouTube. Run
+		"go-sbv-to-srt formats" to list every supported format.
+
 		Examples:
 		go-sbv-to-srt -i input.sbv
 		go-sbv-to-srt -i input.sbv -o output.srt
-		go-sbv-to-srt --input video.sbv --output subtitles.srt`,
-	RunE: convertSbvToSrt,
+		go-sbv-to-srt -i edited.srt -o upload.sbv
+		go-sbv-to-srt -i input.sbv -o output.vtt
+		go-sbv-to-srt -i input.sbv --format vtt --vtt-cue-ids
+		go-sbv-to-srt -i captions.txt --from sbv -o captions.srt
+		go-sbv-to-srt -i broken.sbv --lenient
+		go-sbv-to-srt -i ./archive -r -d ./converted
+		go-sbv-to-srt -i "./archive/*.sbv" --format vtt --jobs 8
+		go-sbv-to-srt --input video.sbv --output subtitles.srt
+		curl -s https://example.com/captions.sbv | go-sbv-to-srt > captions.srt
+		go-sbv-to-srt -i input.sbv -o - --format vtt | less`,
+	RunE: convertSubtitles,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -46,11 +98,7 @@ func SetVersionInfo(v string) {
 // init initializes the root command and its flags
 // It also sets up the version command as a subcommand.
 func init() {
-	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input SBV file path (required)")
-	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output SRT file path (optional - defaults to input filename with .srt extension)")
-	if err := rootCmd.MarkFlagRequired("input"); err != nil {
-		panic(fmt.Sprintf("Failed to mark flag as required: %v", err))
-	}
+	addConversionFlags(rootCmd)
 
 	versionCmd := &cobra.Command{
 		Use:   "version",
@@ -60,45 +108,399 @@ func init() {
 		},
 	}
 	rootCmd.AddCommand(versionCmd)
+
+	formatsCmd := &cobra.Command{
+		Use:   "formats",
+		Short: "List supported subtitle formats",
+		Run: func(cmd *cobra.Command, args []string) {
+			printFormats()
+		},
+	}
+	rootCmd.AddCommand(formatsCmd)
+
+	rootCmd.AddCommand(newShiftCmd())
+	rootCmd.AddCommand(newResyncCmd())
+	rootCmd.AddCommand(newFramerateCmd())
+	rootCmd.AddCommand(newLintCmd())
+}
+
+// addConversionFlags registers the input, output and format flags shared by
+// every command that reads and writes subtitles.
+func addConversionFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVarP(&inputFile, "input", "i", "", "Input subtitle file, directory or glob pattern, or - for stdin (optional - defaults to stdin)")
+	flags.StringVarP(&outputFile, "output", "o", "", "Output subtitle file path, or - for stdout (optional - defaults to input filename with the output format extension, or stdout for stdin input)")
+	flags.StringVar(&inputFormat, "from", "", "Input format name (optional - defaults to the input extension, then content detection)")
+	flags.StringVarP(&outputFormat, "format", "f", "", "Output format name (optional - defaults to the output extension, or the opposite of the input format)")
+	flags.StringVarP(&outputDir, "output-dir", "d", "", "Output directory for directory or glob input, mirroring the source tree (optional - defaults to next to each input)")
+	flags.BoolVarP(&recursive, "recursive", "r", false, "Descend into subdirectories of a directory input")
+	flags.IntVarP(&parallelJobs, "jobs", "j", 0, "Number of files converted in parallel for directory or glob input (optional - defaults to one per CPU)")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "Suppress progress messages on stderr")
+	flags.BoolVar(&force, "force", false, "Overwrite existing output files")
+	flags.BoolVarP(&noClobber, "no-clobber", "n", false, "Skip inputs whose output file already exists")
+	flags.BoolVar(&sortCues, "sort", false, "Order cues by start time before writing")
+	flags.StringVar(&overlapMode, "overlaps", "keep", "How to resolve overlapping cues: keep, trim, merge or stack")
+	flags.DurationVar(&minGap, "min-gap", 0, "Shortest pause kept between consecutive cues (e.g. 80ms)")
+	flags.DurationVar(&mergeShort, "merge-short", 0, "Join cues shorter than this with the next cue (e.g. 1s)")
+	flags.DurationVar(&mergeGap, "merge-gap", 0, "Join cues separated by at most this pause (e.g. 100ms)")
+	flags.DurationVar(&mergeMax, "merge-max", 0, "Never join cues into one longer than this (optional - 0 allows any length)")
+	flags.DurationVar(&splitLong, "split-long", 0, "Split cues longer than this at sentence or punctuation boundaries, timed by text length")
+	flags.IntVar(&wrapWidth, "wrap", 0, "Re-wrap cue text to at most this many columns per line, counting CJK characters as two (optional - 0 keeps the line breaks)")
+	flags.IntVar(&wrapLines, "wrap-lines", 2, "Most lines per cue before --wrap-split splits it (requires --wrap-split)")
+	flags.BoolVar(&wrapBalance, "wrap-balance", true, "Make re-wrapped lines even in length instead of filling the first line")
+	flags.BoolVar(&wrapSplit, "wrap-split", false, "Split cues that need more than --wrap-lines lines into consecutive cues timed by text length")
+	flags.BoolVar(&lenient, "lenient", false, "Skip or repair malformed cues instead of failing, reporting warnings on stderr")
+	flags.StringVar(&inputEncoding, "encoding", "auto", "Input character encoding: auto, utf-8, utf-8-bom, utf-16le, utf-16be or windows-1252")
+	flags.StringVar(&outputEncoding, "output-encoding", "utf-8", "Output character encoding: utf-8, utf-8-bom, utf-16le, utf-16be or windows-1252")
+	flags.StringVar(&lineEnding, "line-ending", "lf", "Output line ending: lf, or crlf for Windows players and broadcast ingest tools")
+	flags.BoolVar(&preserveSpace, "preserve-whitespace", false, "Keep leading and interior whitespace in cue text, such as spaces used for alignment")
+	flags.IntVar(&startIndex, "start-index", 1, "Number of the first SRT cue or VTT cue identifier")
+	flags.StringVar(&srtSeparator, "srt-decimal-separator", ",", "Separator between seconds and milliseconds in SRT timestamps: , or .")
+	flags.IntVar(&sbvHourDigits, "sbv-hour-digits", 1, "Least number of digits written for the hours of SBV timestamps")
+	flags.BoolVar(&vttCueIDs, "vtt-cue-ids", false, "Write numeric cue identifiers in VTT output")
+	flags.StringVar(&vttCueSettings, "vtt-cue-settings", "", "Cue settings appended to every VTT timing line (e.g. \"line:90% align:center\")")
+	flags.StringVar(&assStyleFile, "ass-style-file", "", "ASS or SSA script whose resolution and styles are used for ASS/SSA output; dialogue uses its first style")
+	flags.BoolVar(&youtubeWords, "youtube-words", false, "Make every word-timed segment of SRV3/JSON3 input its own cue, as in automatic captions")
+	flags.StringVar(&ttmlLanguage, "ttml-lang", "en", "Language tag written as xml:lang of TTML output (e.g. en-US, fr)")
+	flags.BoolVar(&assStripTags, "ass-strip-tags", false, "Remove ASS/SSA override tags from input instead of turning italic, bold, underline and strike-out into markup")
+}
+
+// transformFunc modifies parsed subtitles before they are written.
+type transformFunc func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error)
+
+// normalizeOptions builds the normalization options from the command line flags.
+func normalizeOptions() (sbv.NormalizeOptions, error) {
+	overlaps, err := sbv.ParseOverlapStrategy(overlapMode)
+	if err != nil {
+		return sbv.NormalizeOptions{}, err
+	}
+	if minGap < 0 {
+		return sbv.NormalizeOptions{}, fmt.Errorf("--min-gap cannot be negative: %s", minGap)
+	}
+	if mergeShort < 0 || mergeGap < 0 || mergeMax < 0 || splitLong < 0 {
+		return sbv.NormalizeOptions{}, fmt.Errorf("--merge-short, --merge-gap, --merge-max and --split-long cannot be negative")
+	}
+	if wrapWidth < 0 || wrapLines < 0 {
+		return sbv.NormalizeOptions{}, fmt.Errorf("--wrap and --wrap-lines cannot be negative")
+	}
+	return sbv.NormalizeOptions{Sort: sortCues, Overlaps: overlaps, MinGap: minGap}, nil
+}
+
+// checkWrapFlags rejects --wrap-lines without --wrap-split, as re-wrapping
+// never drops text to keep a cue within the line limit.
+func checkWrapFlags(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("wrap-lines") && !wrapSplit {
+		return fmt.Errorf("--wrap-lines requires --wrap-split, as cues are only kept within the line limit by splitting them")
+	}
+	return nil
+}
+
+// prepareSubtitles applies transform, if it is not nil, and then the
+// normalization, merging, splitting and re-wrapping selected on the command
+// line to parsed subtitles.
+func prepareSubtitles(subtitles []sbv.Subtitle, transform transformFunc) ([]sbv.Subtitle, error) {
+	if transform != nil {
+		var err error
+		if subtitles, err = transform(subtitles); err != nil {
+			return nil, err
+		}
+	}
+
+	opts, err := normalizeOptions()
+	if err != nil {
+		return nil, err
+	}
+	subtitles = sbv.Normalize(subtitles, opts)
+	subtitles = sbv.MergeCues(subtitles, sbv.MergeOptions{
+		MinDuration: mergeShort,
+		MaxGap:      mergeGap,
+		MaxDuration: mergeMax,
+	})
+	subtitles = sbv.SplitLongCues(subtitles, splitLong)
+
+	return sbv.Reflow(subtitles, sbv.ReflowOptions{
+		MaxLineWidth: wrapWidth,
+		MaxLines:     wrapLines,
+		Balance:      wrapBalance,
+		SplitCues:    wrapSplit,
+	}), nil
 }
 
-func convertSbvToSrt(cmd *cobra.Command, args []string) error {
-	if err := validateInputFile(inputFile); err != nil {
-		return fmt.Errorf("input validation failed: %w", err)
+func convertSubtitles(cmd *cobra.Command, args []string) error {
+	return runConversion(cmd, nil)
+}
+
+// runConversion reads the input given on the command line, applies transform
+// if it is not nil, and writes the result in the output format.
+func runConversion(cmd *cobra.Command, transform transformFunc) error {
+	if _, err := normalizeOptions(); err != nil {
+		return err
+	}
+	if _, _, err := encodingOptions(); err != nil {
+		return err
+	}
+	if _, err := sbv.ParseLineEnding(lineEnding); err != nil {
+		return err
+	}
+	if _, err := converterOptions(); err != nil {
+		return err
+	}
+	if force && noClobber {
+		return fmt.Errorf("--force and --no-clobber cannot be used together")
+	}
+	if err := checkWrapFlags(cmd); err != nil {
+		return err
+	}
+
+	if isBatchInput(inputFile) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		return convertBatch(ctx, transform)
+	}
+
+	fromStdin := inputFile == "" || inputFile == stdio
+	if inputFile == "" && isTerminal(os.Stdin) {
+		return fmt.Errorf("no input given: use --input or pipe subtitles to standard input")
+	}
+
+	var (
+		input    io.Reader
+		inFormat sbv.Format
+		err      error
+	)
+	if fromStdin {
+		if input, err = textReader(os.Stdin); err != nil {
+			return err
+		}
+		inFormat, input, err = resolveStdinFormat(input, inputFormat)
+		if err != nil {
+			return fmt.Errorf("input validation failed: %w", err)
+		}
+	} else {
+		if err := validateInputFile(inputFile); err != nil {
+			return fmt.Errorf("input validation failed: %w", err)
+		}
+
+		inFormat, err = resolveInputFormat(inputFile, inputFormat)
+		if err != nil {
+			return fmt.Errorf("input validation failed: %w", err)
+		}
+	}
+
+	// Determine output file path, writing to stdout for piped input
+	output := outputFile
+	if output == "" && fromStdin {
+		output = stdio
 	}
 
-	// Determine output file path
-	outputPath, err := determineOutputPath(inputFile, outputFile)
+	var (
+		outputPath string
+		outFormat  sbv.Format
+	)
+	if output == stdio {
+		outputPath = stdio
+		name := outputFormat
+		if name == "" {
+			name = defaultOutputFormat(inFormat)
+		}
+		outFormat, err = resolveOutputFormat("", name)
+	} else {
+		outputPath, err = determineOutputPath(inputFile, output, outputFormat)
+		if err != nil {
+			return fmt.Errorf("output path determination failed: %w", err)
+		}
+		outFormat, err = resolveOutputFormat(outputPath, outputFormat)
+	}
 	if err != nil {
 		return fmt.Errorf("output path determination failed: %w", err)
 	}
 
-	fmt.Printf("Converting SBV file: %s\n", inputFile)
-	fmt.Printf("Output SRT file: %s\n", outputPath)
+	skip, err := checkOutputPath(inputFile, outputPath)
+	if err != nil {
+		return fmt.Errorf("output path determination failed: %w", err)
+	}
+	if skip {
+		logf("Skipping %s: output file %s already exists\n", displayPath(inputFile, "standard input"), outputPath)
+		return nil
+	}
 
-	// Create converter instance
-	converter := sbv.NewConverter()
+	inputName := strings.ToUpper(inFormat.Name)
+	outputName := strings.ToUpper(outFormat.Name)
 
-	// Parse the SBV file
-	subtitles, err := converter.ParseFromFile(inputFile)
+	logf("Converting %s file: %s\n", inputName, displayPath(inputFile, "standard input"))
+	logf("Output %s file: %s\n", outputName, displayPath(outputPath, "standard output"))
+
+	// Parse the input
+	var (
+		subtitles   []sbv.Subtitle
+		diagnostics []sbv.Diagnostic
+	)
+	if fromStdin {
+		subtitles, diagnostics, err = decodeReader(input, inFormat, lenient)
+	} else {
+		subtitles, diagnostics, err = decodeFile(inputFile, inFormat, lenient)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to parse SBV file: %w", err)
+		return fmt.Errorf("failed to parse %s file: %w", inputName, err)
 	}
+	printDiagnostics(os.Stderr, displayPath(inputFile, "<stdin>"), diagnostics)
 
-	fmt.Printf("Parsed %d subtitle entries\n", len(subtitles))
+	logf("Parsed %d subtitle entries\n", len(subtitles))
 
-	// Convert and write to SRT file
-	err = converter.WriteToFile(subtitles, outputPath)
+	if subtitles, err = prepareSubtitles(subtitles, transform); err != nil {
+		return err
+	}
+
+	// Convert and write the output
+	encoder, err := outputEncoder(outFormat)
 	if err != nil {
-		return fmt.Errorf("failed to write SRT file: %w", err)
+		return err
+	}
+	if err := encodeFile(outputPath, encoder, subtitles); err != nil {
+		return fmt.Errorf("failed to write %s file: %w", outputName, err)
 	}
 
-	fmt.Printf("Successfully converted %d subtitles to SRT format\n", len(subtitles))
-	fmt.Printf("Output saved to: %s\n", outputPath)
+	logf("Successfully converted %d subtitles to %s format\n", len(subtitles), outputName)
+	logf("Output saved to: %s\n", displayPath(outputPath, "standard output"))
 
 	return nil
 }
 
+// logf prints a progress message to stderr, keeping stdout free for
+// subtitle output. Nothing is printed with --quiet.
+func logf(format string, args ...any) {
+	fmt.Fprintf(progressOutput(), format, args...)
+}
+
+// progressOutput returns where progress messages go: stderr, or nowhere
+// with --quiet.
+func progressOutput() io.Writer {
+	if quiet {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// displayPath returns path for messages, or name if path stands for a
+// standard stream.
+func displayPath(path, name string) string {
+	if path == "" || path == stdio {
+		return name
+	}
+	return path
+}
+
+// isTerminal reports whether file is an interactive terminal rather than a
+// pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// inputDecoder returns the decoder for the input format, configured from the
+// parsing command line flags.
+func inputDecoder(format sbv.Format) sbv.Decoder {
+	return format.DecoderWith(sbv.DecodeOptions{
+		PreserveWhitespace: preserveSpace,
+		StripTags:          assStripTags,
+		Words:              youtubeWords,
+	})
+}
+
+// converterOptions returns the numbering and timestamp format given on the
+// command line. The output encoding and line ending are applied by
+// encodeFile, so that they cover every output format.
+func converterOptions() ([]sbv.Option, error) {
+	if startIndex < 0 {
+		return nil, fmt.Errorf("--start-index cannot be negative: %d", startIndex)
+	}
+	if srtSeparator != "," && srtSeparator != "." {
+		return nil, fmt.Errorf("--srt-decimal-separator must be , or .: %q", srtSeparator)
+	}
+	if sbvHourDigits < 1 {
+		return nil, fmt.Errorf("--sbv-hour-digits must be at least 1: %d", sbvHourDigits)
+	}
+
+	return []sbv.Option{
+		sbv.WithStartIndex(startIndex),
+		sbv.WithTimeFormat(sbv.TimeFormat{
+			SRTDecimalSeparator: rune(srtSeparator[0]),
+			SBVHourDigits:       sbvHourDigits,
+		}),
+	}, nil
+}
+
+// outputEncoder returns the encoder for the output format, configured from
+// the output command line flags.
+func outputEncoder(format sbv.Format) (sbv.Encoder, error) {
+	converter, err := converterOptions()
+	if err != nil {
+		return nil, err
+	}
+	ass, err := assOptions()
+	if err != nil {
+		return nil, err
+	}
+	_, encoding, err := encodingOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return format.EncoderWith(sbv.EncodeOptions{
+		Converter: converter,
+		VTT: sbv.VTTOptions{
+			CueIdentifiers: vttCueIDs,
+			CueSettings:    vttCueSettings,
+		},
+		ASS:  ass,
+		TTML: sbv.TTMLOptions{Language: ttmlLanguage, Encoding: encoding},
+	}), nil
+}
+
+// assOptions reads the script resolution and styles from --ass-style-file,
+// or returns the default options without one.
+func assOptions() (sbv.ASSOptions, error) {
+	if assStyleFile == "" {
+		return sbv.ASSOptions{}, nil
+	}
+
+	file, err := os.Open(assStyleFile)
+	if err != nil {
+		return sbv.ASSOptions{}, fmt.Errorf("failed to open style file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			// Log the error but don't override the main error
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+
+	opts, err := sbv.ParseASSStyles(file)
+	if err != nil {
+		return sbv.ASSOptions{}, fmt.Errorf("invalid style file %s: %w", assStyleFile, err)
+	}
+	return opts, nil
+}
+
+// printDiagnostics writes lenient parsing diagnostics and a summary to w.
+func printDiagnostics(w io.Writer, path string, diagnostics []sbv.Diagnostic) {
+	if len(diagnostics) == 0 {
+		return
+	}
+
+	warnings, skipped := 0, 0
+	for _, d := range diagnostics {
+		if d.Severity == sbv.SeverityError {
+			skipped++
+		} else {
+			warnings++
+		}
+		fmt.Fprintf(w, "%s:%s\n", path, d)
+	}
+
+	fmt.Fprintf(w, "Lenient parsing: %d warning(s), %d cue(s) skipped\n", warnings, skipped)
+}
+
 func validateInputFile(input string) error {
 	if input == "" {
 		return fmt.Errorf("input file path cannot be empty")
@@ -109,12 +511,6 @@ func validateInputFile(input string) error {
 		return fmt.Errorf("input file does not exist: %s", input)
 	}
 
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(input))
-	if ext != ".sbv" {
-		return fmt.Errorf("input file must have .sbv extension, got: %s", ext)
-	}
-
 	file, err := os.Open(input)
 	if err != nil {
 		return fmt.Errorf("cannot read input file: %w", err)
@@ -129,7 +525,7 @@ func validateInputFile(input string) error {
 	return nil
 }
 
-func determineOutputPath(input, output string) (string, error) {
+func determineOutputPath(input, output, format string) (string, error) {
 	if output != "" {
 		outputDir := filepath.Dir(output)
 		if outputDir != "." {
@@ -139,17 +535,32 @@ func determineOutputPath(input, output string) (string, error) {
 			}
 		}
 
-		// Ensure output has .srt extension
-		if !strings.HasSuffix(strings.ToLower(output), ".srt") {
-			return "", fmt.Errorf("output file must have .srt extension")
+		// Ensure output has an extension the chosen format can write
+		if _, err := resolveOutputFormat(output, format); err != nil {
+			return "", err
 		}
 
 		return output, nil
 	}
 
 	// Generate output filename from input
-	inputBase := strings.TrimSuffix(input, filepath.Ext(input))
-	outputPath := inputBase + ".srt"
+	inputExt := filepath.Ext(input)
+	inputBase := strings.TrimSuffix(input, inputExt)
+	if format != "" {
+		f, err := resolveOutputFormat("", format)
+		if err != nil {
+			return "", err
+		}
+		if len(f.Extensions) == 0 {
+			return "", fmt.Errorf("format %s has no file extension, an output path is required", f.Name)
+		}
+		return inputBase + f.Extensions[0], nil
+	}
+
+	// Without an explicit format, flip the conversion direction
+	if strings.ToLower(inputExt) == ".srt" {
+		return inputBase + ".sbv", nil
+	}
 
-	return outputPath, nil
+	return inputBase + ".srt", nil
 }
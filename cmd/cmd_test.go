@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
 )
 
 func TestValidateInputFile(t *testing.T) {
@@ -21,6 +27,19 @@ func TestValidateInputFile(t *testing.T) {
 		t.Fatalf("Failed to close temp file: %v", err)
 	}
 
+	srtFile, err := os.CreateTemp("", "test*.srt")
+	if err != nil {
+		t.Fatalf("Failed to create temp srt file: %v", err)
+	}
+	defer func() {
+		if err := os.Remove(srtFile.Name()); err != nil {
+			t.Logf("Warning: failed to remove temp srt file: %v", err)
+		}
+	}()
+	if err := srtFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp srt file: %v", err)
+	}
+
 	tests := []struct {
 		name    string
 		input   string
@@ -39,38 +58,20 @@ func TestValidateInputFile(t *testing.T) {
 			wantErr: true,
 			errMsg:  "input file does not exist",
 		},
-		{
-			name:    "wrong extension",
-			input:   tempFile.Name() + ".txt",
-			wantErr: true,
-			errMsg:  "input file must have .sbv extension",
-		},
 		{
 			name:    "valid sbv file",
 			input:   tempFile.Name(),
 			wantErr: false,
 		},
+		{
+			name:    "valid srt file",
+			input:   srtFile.Name(),
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// For the wrong extension test, create a file with .txt extension
-			if tt.name == "wrong extension" {
-				txtFile, err := os.CreateTemp("", "test*.txt")
-				if err != nil {
-					t.Fatalf("Failed to create temp txt file: %v", err)
-				}
-				defer func() {
-					if err := os.Remove(txtFile.Name()); err != nil {
-						t.Logf("Warning: failed to remove temp txt file: %v", err)
-					}
-				}()
-				if err := txtFile.Close(); err != nil {
-					t.Fatalf("Failed to close temp txt file: %v", err)
-				}
-				tt.input = txtFile.Name()
-			}
-
 			err := validateInputFile(tt.input)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validateInputFile() error = %v, wantErr %v", err, tt.wantErr)
@@ -101,6 +102,7 @@ func TestDetermineOutputPath(t *testing.T) {
 		name    string
 		input   string
 		output  string
+		format  string
 		want    string
 		wantErr bool
 		errMsg  string
@@ -117,6 +119,12 @@ func TestDetermineOutputPath(t *testing.T) {
 			output: "",
 			want:   "/path/to/video.srt",
 		},
+		{
+			name:   "auto-generate sbv output from srt input",
+			input:  "/path/to/video.srt",
+			output: "",
+			want:   "/path/to/video.sbv",
+		},
 		{
 			name:   "explicit output file",
 			input:  "video.sbv",
@@ -129,12 +137,51 @@ func TestDetermineOutputPath(t *testing.T) {
 			output: filepath.Join(tempDir, "output.srt"),
 			want:   filepath.Join(tempDir, "output.srt"),
 		},
+		{
+			name:   "explicit sbv output file",
+			input:  "video.srt",
+			output: "upload.sbv",
+			want:   "upload.sbv",
+		},
+		{
+			name:   "explicit vtt output file",
+			input:  "video.sbv",
+			output: "browser.vtt",
+			want:   "browser.vtt",
+		},
+		{
+			name:   "auto-generate with vtt format",
+			input:  "/path/to/video.sbv",
+			format: "vtt",
+			want:   "/path/to/video.vtt",
+		},
+		{
+			name:   "format is case-insensitive",
+			input:  "video.sbv",
+			format: "SRT",
+			want:   "video.srt",
+		},
+		{
+			name:    "format does not match output extension",
+			input:   "video.sbv",
+			output:  "output.srt",
+			format:  "vtt",
+			wantErr: true,
+			errMsg:  "does not match format vtt",
+		},
+		{
+			name:    "unsupported format",
+			input:   "video.sbv",
+			format:  "xyz",
+			wantErr: true,
+			errMsg:  "unsupported output format: xyz",
+		},
 		{
 			name:    "output without .srt extension",
 			input:   "video.sbv",
 			output:  "output.txt",
 			wantErr: true,
-			errMsg:  "output file must have .srt extension",
+			errMsg:  "output file must have a supported extension",
 		},
 		{
 			name:    "output directory doesn't exist",
@@ -147,7 +194,7 @@ func TestDetermineOutputPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := determineOutputPath(tt.input, tt.output)
+			got, err := determineOutputPath(tt.input, tt.output, tt.format)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("determineOutputPath() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -164,6 +211,131 @@ func TestDetermineOutputPath(t *testing.T) {
 	}
 }
 
+func TestPrintDiagnostics(t *testing.T) {
+	var buf bytes.Buffer
+	printDiagnostics(&buf, "in.sbv", nil)
+	if buf.Len() != 0 {
+		t.Errorf("printDiagnostics() with no diagnostics wrote %q", buf.String())
+	}
+
+	printDiagnostics(&buf, "in.sbv", []sbv.Diagnostic{
+		{Line: 1, Severity: sbv.SeverityWarning, Text: "junk", Message: "text outside of a cue", Action: "ignored line"},
+		{Line: 4, Column: 13, Severity: sbv.SeverityError, Text: "0:00:03.000,0:xx:04.000", Message: "bad time", Action: "skipped cue"},
+	})
+
+	want := "in.sbv:1: warning: text outside of a cue (ignored line): \"junk\"\n" +
+		"in.sbv:4:13: error: bad time (skipped cue): \"0:00:03.000,0:xx:04.000\"\n" +
+		"Lenient parsing: 1 warning(s), 1 cue(s) skipped\n"
+	if buf.String() != want {
+		t.Errorf("printDiagnostics() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPrepareSubtitles(t *testing.T) {
+	savedSort, savedOverlaps, savedGap := sortCues, overlapMode, minGap
+	savedWidth, savedSplit := wrapWidth, wrapSplit
+	savedShort, savedLong := mergeShort, splitLong
+	defer func() {
+		sortCues, overlapMode, minGap = savedSort, savedOverlaps, savedGap
+		wrapWidth, wrapSplit = savedWidth, savedSplit
+		mergeShort, splitLong = savedShort, savedLong
+	}()
+
+	subtitles := []sbv.Subtitle{
+		{StartTime: 3 * time.Second, EndTime: 5 * time.Second, Text: "B"},
+		{StartTime: 1 * time.Second, EndTime: 4 * time.Second, Text: "A"},
+	}
+	shift := func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+		return sbv.Shift(subtitles, time.Second), nil
+	}
+
+	sortCues, overlapMode, minGap = true, "trim", 0
+	got, err := prepareSubtitles(subtitles, shift)
+	if err != nil {
+		t.Fatalf("prepareSubtitles() unexpected error: %v", err)
+	}
+	want := []sbv.Subtitle{
+		{StartTime: 2 * time.Second, EndTime: 4 * time.Second, Text: "A"},
+		{StartTime: 4 * time.Second, EndTime: 6 * time.Second, Text: "B"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("prepareSubtitles() = %v, want %v", got, want)
+	}
+
+	wrapWidth, wrapSplit = 10, true
+	got, err = prepareSubtitles([]sbv.Subtitle{{StartTime: 0, EndTime: 3 * time.Second, Text: "one two\nthree four five six"}}, nil)
+	if err != nil {
+		t.Fatalf("prepareSubtitles() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Text != "one two\nthree four" || got[1].Text != "five six" {
+		t.Errorf("prepareSubtitles() with wrapping = %q", got)
+	}
+	wrapWidth, wrapSplit = 0, false
+
+	mergeShort, splitLong = time.Second, 4*time.Second
+	got, err = prepareSubtitles([]sbv.Subtitle{
+		{StartTime: 0, EndTime: 500 * time.Millisecond, Text: "So"},
+		{StartTime: 500 * time.Millisecond, EndTime: 2 * time.Second, Text: "we went."},
+		{StartTime: 3 * time.Second, EndTime: 9 * time.Second, Text: "It rained. We ran."},
+	}, nil)
+	if err != nil {
+		t.Fatalf("prepareSubtitles() unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[0].Text != "So we went." || got[1].Text != "It rained." || got[2].Text != "We ran." {
+		t.Errorf("prepareSubtitles() with merging and splitting = %q", got)
+	}
+	mergeShort, splitLong = 0, 0
+
+	overlapMode = "shuffle"
+	if _, err := prepareSubtitles(subtitles, nil); err == nil || !contains(err.Error(), "unknown overlap strategy") {
+		t.Errorf("prepareSubtitles() error = %v, want unknown overlap strategy", err)
+	}
+
+	overlapMode, minGap = "keep", -time.Second
+	if _, err := prepareSubtitles(subtitles, nil); err == nil {
+		t.Errorf("prepareSubtitles() expected error for negative minimum gap, got nil")
+	}
+
+	minGap, splitLong = 0, -time.Second
+	if _, err := prepareSubtitles(subtitles, nil); err == nil {
+		t.Errorf("prepareSubtitles() expected error for negative split duration, got nil")
+	}
+}
+
+func TestCheckWrapFlags(t *testing.T) {
+	savedLines, savedSplit := wrapLines, wrapSplit
+	defer func() {
+		wrapLines, wrapSplit = savedLines, savedSplit
+	}()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "defaults", args: nil},
+		{name: "split with default lines", args: []string{"--wrap-split"}},
+		{name: "lines with split", args: []string{"--wrap-lines", "3", "--wrap-split"}},
+		{name: "lines without split", args: []string{"--wrap-lines", "3"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().IntVar(&wrapLines, "wrap-lines", 2, "")
+			cmd.Flags().BoolVar(&wrapSplit, "wrap-split", false, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags() unexpected error: %v", err)
+			}
+
+			err := checkWrapFlags(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkWrapFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
@@ -178,3 +350,95 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestOutputEncoder(t *testing.T) {
+	savedIndex, savedSeparator, savedDigits, savedLanguage := startIndex, srtSeparator, sbvHourDigits, ttmlLanguage
+	savedEncoding := outputEncoding
+	defer func() {
+		startIndex, srtSeparator, sbvHourDigits, ttmlLanguage = savedIndex, savedSeparator, savedDigits, savedLanguage
+		outputEncoding = savedEncoding
+	}()
+
+	subtitles := []sbv.Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}}
+	encode := func(name string) string {
+		t.Helper()
+		format, _ := sbv.Lookup(name)
+		encoder, err := outputEncoder(format)
+		if err != nil {
+			t.Fatalf("outputEncoder(%s) unexpected error: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := encoder.Encode(&buf, subtitles); err != nil {
+			t.Fatalf("Encode() unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+
+	startIndex, srtSeparator, sbvHourDigits = 0, ".", 2
+	if got, want := encode("srt"), "0\n00:00:01.000 --> 00:00:02.000\nHi\n\n"; got != want {
+		t.Errorf("SRT output = %q, want %q", got, want)
+	}
+	if got, want := encode("sbv"), "00:00:01.000,00:00:02.000\nHi\n"; got != want {
+		t.Errorf("SBV output = %q, want %q", got, want)
+	}
+
+	ttmlLanguage = "fr-CA"
+	if got := encode("ttml"); !contains(got, `xml:lang="fr-CA"`) || !contains(got, `<p begin="00:00:01.000" end="00:00:02.000">Hi</p>`) {
+		t.Errorf("TTML output = %q, want language fr-CA and one cue", got)
+	}
+	outputEncoding = "windows-1252"
+	if got := encode("ttml"); !contains(got, `<?xml version="1.0" encoding="windows-1252"?>`) {
+		t.Errorf("TTML output = %q, want the output encoding declared", got)
+	}
+	outputEncoding = savedEncoding
+
+	for _, bad := range []func(){
+		func() { startIndex = -1 },
+		func() { startIndex, srtSeparator = 1, ";" },
+		func() { srtSeparator, sbvHourDigits = ",", 0 },
+	} {
+		bad()
+		if _, err := converterOptions(); err == nil {
+			t.Errorf("converterOptions() expected error for start index %d, separator %q, hour digits %d",
+				startIndex, srtSeparator, sbvHourDigits)
+		}
+	}
+}
+
+func TestOutputEncoderASSStyleFile(t *testing.T) {
+	saved := assStyleFile
+	defer func() { assStyleFile = saved }()
+
+	dir := t.TempDir()
+	assStyleFile = filepath.Join(dir, "styles.ass")
+	styles := "[Script Info]\nPlayResX: 1280\nPlayResY: 720\n\n[V4+ Styles]\nFormat: Name, Fontname, Fontsize\nStyle: Fansub,Comic Sans MS,50\n"
+	if err := os.WriteFile(assStyleFile, []byte(styles), 0o644); err != nil {
+		t.Fatalf("Failed to write style file: %v", err)
+	}
+
+	subtitles := []sbv.Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi\nthere"}}
+	for _, name := range []string{"ass", "ssa"} {
+		format, _ := sbv.Lookup(name)
+		encoder, err := outputEncoder(format)
+		if err != nil {
+			t.Fatalf("outputEncoder(%s) unexpected error: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := encoder.Encode(&buf, subtitles); err != nil {
+			t.Fatalf("Encode() unexpected error: %v", err)
+		}
+		output := buf.String()
+		if !contains(output, "PlayResX: 1280\n") || !contains(output, "Style: Fansub,Comic Sans MS,50,") || !contains(output, ",Fansub,,0,0,0,,Hi\\Nthere\n") {
+			t.Errorf("%s output does not use the style file:\n%s", name, output)
+		}
+		if (name == "ssa") != contains(output, "[V4 Styles]") {
+			t.Errorf("%s output has the wrong styles section:\n%s", name, output)
+		}
+	}
+
+	assStyleFile = filepath.Join(dir, "missing.ass")
+	format, _ := sbv.Lookup("ass")
+	if _, err := outputEncoder(format); err == nil {
+		t.Errorf("outputEncoder() expected error for a missing style file, got nil")
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+// resolveInputFormat picks the format of an input file from an explicit
+// format name, the file extension, or finally the file content.
+func resolveInputFormat(path, name string) (sbv.Format, error) {
+	format, ok := sbv.Lookup(name)
+	if name != "" && !ok {
+		return sbv.Format{}, fmt.Errorf("unsupported input format: %s", name)
+	}
+
+	if name == "" {
+		if format, ok = sbv.LookupExtension(filepath.Ext(path)); !ok {
+			detected, err := detectFileFormat(path)
+			if err != nil {
+				return sbv.Format{}, fmt.Errorf("cannot determine format of %s (use --from): %w", path, err)
+			}
+			format = detected
+		}
+	}
+
+	if !format.CanDecode() {
+		return sbv.Format{}, fmt.Errorf("format %s cannot be used as input", format.Name)
+	}
+
+	return format, nil
+}
+
+// resolveOutputFormat picks the format of an output file from an explicit
+// format name or the file extension. An explicit name must agree with the
+// extension of a non-empty path.
+func resolveOutputFormat(path, name string) (sbv.Format, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if name != "" {
+		format, ok := sbv.Lookup(name)
+		if !ok || !format.CanEncode() {
+			return sbv.Format{}, fmt.Errorf("unsupported output format: %s (must be one of %s)", name, strings.Join(encodableNames(), ", "))
+		}
+		if path != "" && !hasExtension(format, ext) {
+			return sbv.Format{}, fmt.Errorf("output file extension %s does not match format %s", ext, format.Name)
+		}
+		return format, nil
+	}
+
+	format, ok := sbv.LookupExtension(ext)
+	if !ok || !format.CanEncode() {
+		return sbv.Format{}, fmt.Errorf("output file must have a supported extension (%s)", strings.Join(encodableExtensions(), ", "))
+	}
+
+	return format, nil
+}
+
+// detectFileFormat sniffs the content of a file to find its format.
+func detectFileFormat(path string) (sbv.Format, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sbv.Format{}, err
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			// Log the error but don't override the main error
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+
+	reader, err := textReader(file)
+	if err != nil {
+		return sbv.Format{}, err
+	}
+	format, _, err := sbv.DetectReader(reader)
+	return format, err
+}
+
+// resolveStdinFormat picks the format of standard input from an explicit
+// format name, or by sniffing the start of reader. The returned reader yields
+// the complete input, including any sniffed bytes.
+func resolveStdinFormat(reader io.Reader, name string) (sbv.Format, io.Reader, error) {
+	if name != "" {
+		format, err := resolveInputFormat("", name)
+		return format, reader, err
+	}
+
+	format, reader, err := sbv.DetectReader(reader)
+	if err != nil {
+		return sbv.Format{}, nil, fmt.Errorf("cannot determine format of standard input (use --from): %w", err)
+	}
+	if !format.CanDecode() {
+		return sbv.Format{}, nil, fmt.Errorf("format %s cannot be used as input", format.Name)
+	}
+
+	return format, reader, nil
+}
+
+// defaultOutputFormat returns the output format name used when neither an
+// output extension nor --format picks one: the opposite of the input format.
+func defaultOutputFormat(input sbv.Format) string {
+	if input.Name == "srt" {
+		return "sbv"
+	}
+	return "srt"
+}
+
+// decodeFile opens a file and parses it with the format's decoder, decoding
+// its text from the input encoding given on the command line. In lenient
+// mode malformed cues are skipped or repaired and reported as diagnostics.
+func decodeFile(path string, format sbv.Format, lenient bool) ([]sbv.Subtitle, []sbv.Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			// Log the error but don't override the main error
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+
+	reader, err := textReader(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decodeReader(reader, format, lenient)
+}
+
+// decodeReader parses reader with the format's decoder, leniently if requested.
+func decodeReader(reader io.Reader, format sbv.Format, lenient bool) ([]sbv.Subtitle, []sbv.Diagnostic, error) {
+	decoder := inputDecoder(format)
+	if lenient {
+		lenientDecoder, ok := decoder.(sbv.LenientDecoder)
+		if !ok {
+			return nil, nil, fmt.Errorf("format %s does not support lenient parsing", format.Name)
+		}
+		return lenientDecoder.DecodeLenient(reader)
+	}
+
+	subtitles, err := decoder.Decode(reader)
+	return subtitles, nil, err
+}
+
+// encodeFile creates a file and writes subtitles to it with the given encoder,
+// in the output encoding and line ending given on the command line. The file
+// is only replaced once everything has been written. A path of "-" writes to
+// standard output instead.
+func encodeFile(path string, encoder sbv.Encoder, subtitles []sbv.Subtitle) error {
+	_, encoding, err := encodingOptions()
+	if err != nil {
+		return err
+	}
+	ending, err := sbv.ParseLineEnding(lineEnding)
+	if err != nil {
+		return err
+	}
+	textWriter := func(writer io.Writer) io.Writer {
+		return sbv.NewLineEndingWriter(sbv.NewTextWriter(writer, encoding), ending)
+	}
+
+	if path == stdio {
+		buffered := bufio.NewWriter(os.Stdout)
+		if err := encoder.Encode(textWriter(buffered), subtitles); err != nil {
+			return err
+		}
+		if err := buffered.Flush(); err != nil {
+			return fmt.Errorf("failed to write to standard output: %w", err)
+		}
+		return nil
+	}
+
+	return sbv.WriteFileAtomic(path, func(writer io.Writer) error {
+		return encoder.Encode(textWriter(writer), subtitles)
+	})
+}
+
+// errOutputExists reports an output file that already exists and was not
+// overwritten.
+var errOutputExists = errors.New("output file already exists (use --force to overwrite or --no-clobber to skip)")
+
+// checkOutputPath reports whether output can be written for input. Writing
+// over the input file is always refused. An existing output file is an error
+// wrapping errOutputExists unless --force or --no-clobber is given; with
+// --no-clobber, skip is true and the file should be left alone.
+func checkOutputPath(input, output string) (skip bool, err error) {
+	if output == stdio {
+		return false, nil
+	}
+
+	outputInfo, err := os.Stat(output)
+	if err != nil {
+		// A missing file is created, and other problems show up when writing
+		return false, nil
+	}
+	if input != "" && input != stdio {
+		if inputInfo, err := os.Stat(input); err == nil && os.SameFile(inputInfo, outputInfo) {
+			return false, fmt.Errorf("refusing to overwrite the input file %s", input)
+		}
+	}
+
+	switch {
+	case force:
+		return false, nil
+	case noClobber:
+		return true, nil
+	}
+	return false, fmt.Errorf("%s: %w", output, errOutputExists)
+}
+
+// encodingOptions parses the input and output encodings given on the
+// command line.
+func encodingOptions() (in, out sbv.Encoding, err error) {
+	if in, err = sbv.ParseEncoding(inputEncoding); err != nil {
+		return in, out, err
+	}
+	if out, err = sbv.ParseEncoding(outputEncoding); err != nil {
+		return in, out, err
+	}
+	if out == sbv.EncodingAuto {
+		return in, out, fmt.Errorf("output encoding cannot be auto")
+	}
+	return in, out, nil
+}
+
+// textReader decodes reader to UTF-8 from the input encoding given on the
+// command line, or the encoding detected at its start.
+func textReader(reader io.Reader) (io.Reader, error) {
+	encoding, _, err := encodingOptions()
+	if err != nil {
+		return nil, err
+	}
+	return sbv.NewTextReader(reader, encoding), nil
+}
+
+// hasExtension reports whether ext is one of the format's extensions.
+func hasExtension(format sbv.Format, ext string) bool {
+	for _, e := range format.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// encodableNames lists the names of all formats that can be written.
+func encodableNames() []string {
+	var names []string
+	for _, format := range sbv.Formats() {
+		if format.CanEncode() {
+			names = append(names, format.Name)
+		}
+	}
+	return names
+}
+
+// encodableExtensions lists the extensions of all formats that can be written.
+func encodableExtensions() []string {
+	var extensions []string
+	for _, format := range sbv.Formats() {
+		if format.CanEncode() {
+			extensions = append(extensions, format.Extensions...)
+		}
+	}
+	return extensions
+}
+
+// printFormats writes a table of all registered formats to stdout.
+func printFormats() {
+	for _, format := range sbv.Formats() {
+		var modes []string
+		if format.CanDecode() {
+			modes = append(modes, "read")
+		}
+		if format.CanEncode() {
+			modes = append(modes, "write")
+		}
+		fmt.Printf("%-6s %-22s %-12s %s\n", format.Name, format.Description,
+			strings.Join(format.Extensions, ","), strings.Join(modes, "/"))
+	}
+}
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+func TestResolveInputFormat(t *testing.T) {
+	tempDir := t.TempDir()
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	sbvText := writeFile("captions.txt", "0:00:01.000,0:00:04.000\nHello\n")
+	srtText := writeFile("edited.txt", "1\n00:00:01,000 --> 00:00:04,000\nHello\n")
+	unknown := writeFile("notes.txt", "just some notes\n")
+	ttmlText := writeFile("vendor.xml", "<?xml version=\"1.0\"?>\n<tt xmlns=\"http://www.w3.org/ns/ttml\"><body/></tt>\n")
+	json3Text := writeFile("video.en.json", "{\"wireMagic\":\"pb3\",\"events\":[]}\n")
+	vttFile := writeFile("browser.vtt", "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nHello\n")
+
+	tests := []struct {
+		name     string
+		path     string
+		format   string
+		wantName string
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "extension",
+			path:     "video.SRT",
+			wantName: "srt",
+		},
+		{
+			name:     "explicit format overrides extension",
+			path:     "video.srt",
+			format:   "sbv",
+			wantName: "sbv",
+		},
+		{
+			name:     "sniff sbv content",
+			path:     sbvText,
+			wantName: "sbv",
+		},
+		{
+			name:     "sniff srt content",
+			path:     srtText,
+			wantName: "srt",
+		},
+		{
+			name:     "dfxp extension",
+			path:     "vendor.dfxp",
+			wantName: "ttml",
+		},
+		{
+			name:     "sniff ttml content",
+			path:     ttmlText,
+			wantName: "ttml",
+		},
+		{
+			name:     "sniff json3 content",
+			path:     json3Text,
+			wantName: "json3",
+		},
+		{
+			name:    "undetectable content",
+			path:    unknown,
+			wantErr: true,
+			errMsg:  "use --from",
+		},
+		{
+			name:    "unknown format name",
+			path:    "video.sbv",
+			format:  "xyz",
+			wantErr: true,
+			errMsg:  "unsupported input format: xyz",
+		},
+		{
+			name:    "write-only format",
+			path:    vttFile,
+			wantErr: true,
+			errMsg:  "format vtt cannot be used as input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveInputFormat(tt.path, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveInputFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("resolveInputFormat() error = %v, want error containing %v", err, tt.errMsg)
+				}
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("resolveInputFormat() = %v, want %v", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestResolveOutputFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		format   string
+		wantName string
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "extension",
+			path:     "out.vtt",
+			wantName: "vtt",
+		},
+		{
+			name:     "format without path",
+			format:   "SBV",
+			wantName: "sbv",
+		},
+		{
+			name:     "format matching extension",
+			path:     "out.srt",
+			format:   "srt",
+			wantName: "srt",
+		},
+		{
+			name:    "format not matching extension",
+			path:    "out.srt",
+			format:  "sbv",
+			wantErr: true,
+			errMsg:  "does not match format sbv",
+		},
+		{
+			name:    "unknown extension",
+			path:    "out.txt",
+			wantErr: true,
+			errMsg:  "output file must have a supported extension",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOutputFormat(tt.path, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveOutputFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("resolveOutputFormat() error = %v, want error containing %v", err, tt.errMsg)
+				}
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("resolveOutputFormat() = %v, want %v", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDecodeFileLenient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.sbv")
+	content := "0:00:01.000,0:00:02.000\nGood\n\n0:00:03.000,0:xx:04.000\nBad\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	format, ok := sbv.Lookup("sbv")
+	if !ok {
+		t.Fatal("sbv format is not registered")
+	}
+
+	if _, _, err := decodeFile(path, format, false); err == nil {
+		t.Errorf("decodeFile() strict expected error, got nil")
+	}
+
+	subtitles, diagnostics, err := decodeFile(path, format, true)
+	if err != nil {
+		t.Fatalf("decodeFile() lenient unexpected error: %v", err)
+	}
+	if len(subtitles) != 1 || len(diagnostics) != 1 {
+		t.Errorf("decodeFile() lenient = %d subtitles, %d diagnostics, want 1 and 1", len(subtitles), len(diagnostics))
+	}
+
+	strictOnly := sbv.Format{Name: "strict-only", Decoder: sbv.DecoderFunc(func(io.Reader) ([]sbv.Subtitle, error) {
+		return nil, nil
+	})}
+	if _, _, err := decodeFile(path, strictOnly, true); err == nil || !contains(err.Error(), "does not support lenient parsing") {
+		t.Errorf("decodeFile() error = %v, want lenient support error", err)
+	}
+}
+
+func TestFileEncodings(t *testing.T) {
+	savedIn, savedOut := inputEncoding, outputEncoding
+	defer func() { inputEncoding, outputEncoding = savedIn, savedOut }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "legacy.txt")
+	if err := os.WriteFile(input, []byte("0:00:01.000,0:00:02.000\nCaf\xe9\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	inputEncoding, outputEncoding = "windows-1252", "utf-16le"
+	format, err := resolveInputFormat(input, "")
+	if err != nil || format.Name != "sbv" {
+		t.Fatalf("resolveInputFormat() = %s, %v, want sbv", format.Name, err)
+	}
+	subtitles, _, err := decodeFile(input, format, false)
+	if err != nil {
+		t.Fatalf("decodeFile() unexpected error: %v", err)
+	}
+	if len(subtitles) != 1 || subtitles[0].Text != "Café" {
+		t.Fatalf("decodeFile() = %q, want one cue with text Café", subtitles)
+	}
+
+	output := filepath.Join(dir, "out.srt")
+	srt, _ := sbv.Lookup("srt")
+	if err := encodeFile(output, srt.Encoder, subtitles); err != nil {
+		t.Fatalf("encodeFile() unexpected error: %v", err)
+	}
+	written, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if len(written) < 2 || written[0] != 0xFF || written[1] != 0xFE {
+		t.Errorf("encodeFile() output starts with % x, want a UTF-16LE byte order mark", written[:min(len(written), 2)])
+	}
+
+	inputEncoding = "auto"
+	if got, _, err := decodeFile(output, srt, false); err != nil || len(got) != 1 || got[0].Text != "Café" {
+		t.Errorf("decodeFile() of UTF-16 output = %q, %v, want one cue with text Café", got, err)
+	}
+
+	outputEncoding = "auto"
+	if err := encodeFile(output, srt.Encoder, subtitles); err == nil {
+		t.Errorf("encodeFile() expected error for auto output encoding, got nil")
+	}
+}
+
+func TestLineEndingAndWhitespace(t *testing.T) {
+	savedEnding, savedPreserve := lineEnding, preserveSpace
+	defer func() { lineEnding, preserveSpace = savedEnding, savedPreserve }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "aligned.sbv")
+	if err := os.WriteFile(input, []byte("0:00:01.000,0:00:02.000\r\n   Right\r\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	format, _ := sbv.Lookup("sbv")
+
+	lineEnding, preserveSpace = "crlf", true
+	subtitles, _, err := decodeFile(input, format, false)
+	if err != nil || len(subtitles) != 1 || subtitles[0].Text != "   Right" {
+		t.Fatalf("decodeFile() = %q, %v, want one cue with text \"   Right\"", subtitles, err)
+	}
+
+	output := filepath.Join(dir, "aligned.srt")
+	srt, _ := sbv.Lookup("srt")
+	if err := encodeFile(output, srt.Encoder, subtitles); err != nil {
+		t.Fatalf("encodeFile() unexpected error: %v", err)
+	}
+	written, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := "1\r\n00:00:01,000 --> 00:00:02,000\r\n   Right\r\n\r\n"; string(written) != want {
+		t.Errorf("encodeFile() wrote %q, want %q", written, want)
+	}
+
+	lineEnding = "cr"
+	if err := encodeFile(output, srt.Encoder, subtitles); err == nil {
+		t.Errorf("encodeFile() expected error for unknown line ending, got nil")
+	}
+}
+
+func TestResolveStdinFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		format   string
+		wantName string
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "sniffed sbv",
+			input:    "0:00:01.000,0:00:04.000\nHello\n",
+			wantName: "sbv",
+		},
+		{
+			name:     "sniffed srt",
+			input:    "1\n00:00:01,000 --> 00:00:04,000\nHello\n",
+			wantName: "srt",
+		},
+		{
+			name:     "explicit format",
+			input:    "anything",
+			format:   "srt",
+			wantName: "srt",
+		},
+		{
+			name:    "unknown content",
+			input:   "just some notes\n",
+			wantErr: true,
+			errMsg:  "cannot determine format of standard input",
+		},
+		{
+			name:    "write-only format",
+			input:   "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nHello\n",
+			wantErr: true,
+			errMsg:  "format vtt cannot be used as input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, reader, err := resolveStdinFormat(strings.NewReader(tt.input), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveStdinFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("resolveStdinFormat() error = %v, want error containing %v", err, tt.errMsg)
+				}
+				return
+			}
+			if format.Name != tt.wantName {
+				t.Errorf("resolveStdinFormat() = %v, want %v", format.Name, tt.wantName)
+			}
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Failed to read returned reader: %v", err)
+			}
+			if string(content) != tt.input {
+				t.Errorf("resolveStdinFormat() reader yields %q, want the complete input %q", content, tt.input)
+			}
+		})
+	}
+}
+
+func TestConvertStdinToStdout(t *testing.T) {
+	tempDir := t.TempDir()
+	stdinPath := filepath.Join(tempDir, "stdin")
+	stdoutPath := filepath.Join(tempDir, "stdout")
+	if err := os.WriteFile(stdinPath, []byte("0:00:01.000,0:00:02.000\nHello\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write stdin: %v", err)
+	}
+
+	stdin, err := os.Open(stdinPath)
+	if err != nil {
+		t.Fatalf("Failed to open stdin: %v", err)
+	}
+	defer stdin.Close()
+	stdout, err := os.Create(stdoutPath)
+	if err != nil {
+		t.Fatalf("Failed to create stdout: %v", err)
+	}
+	defer stdout.Close()
+
+	savedStdin, savedStdout := os.Stdin, os.Stdout
+	savedInput, savedOutput, savedQuiet := inputFile, outputFile, quiet
+	defer func() {
+		os.Stdin, os.Stdout = savedStdin, savedStdout
+		inputFile, outputFile, quiet = savedInput, savedOutput, savedQuiet
+	}()
+	os.Stdin, os.Stdout = stdin, stdout
+	inputFile, outputFile, quiet = "", "", true
+
+	if err := convertSubtitles(rootCmd, nil); err != nil {
+		t.Fatalf("convertSubtitles() unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(stdoutPath)
+	if err != nil {
+		t.Fatalf("Failed to read stdout: %v", err)
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n"
+	if string(content) != want {
+		t.Errorf("convertSubtitles() wrote %q to stdout, want %q", content, want)
+	}
+}
+
+func TestCheckOutputPath(t *testing.T) {
+	savedForce, savedNoClobber := force, noClobber
+	defer func() { force, noClobber = savedForce, savedNoClobber }()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.srt")
+	existing := filepath.Join(dir, "out.srt")
+	for _, path := range []string{input, existing} {
+		if err := os.WriteFile(path, []byte("content"), 0o644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	if err := os.Link(input, filepath.Join(dir, "link.srt")); err != nil {
+		t.Skipf("Hard links are not supported: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		output    string
+		force     bool
+		noClobber bool
+		wantSkip  bool
+		wantErr   bool
+	}{
+		{"new file", filepath.Join(dir, "new.srt"), false, false, false, false},
+		{"stdout", stdio, false, false, false, false},
+		{"existing file", existing, false, false, false, true},
+		{"existing file with force", existing, true, false, false, false},
+		{"existing file with no-clobber", existing, false, true, true, false},
+		{"input file", input, false, false, false, true},
+		{"input file with force", input, true, false, false, true},
+		{"input file through a link", filepath.Join(dir, "link.srt"), true, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			force, noClobber = tt.force, tt.noClobber
+			skip, err := checkOutputPath(input, tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkOutputPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if skip != tt.wantSkip {
+				t.Errorf("checkOutputPath() skip = %v, want %v", skip, tt.wantSkip)
+			}
+		})
+	}
+}
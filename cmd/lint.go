@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+// lintReport is the JSON output of the lint subcommand.
+type lintReport struct {
+	File   string      `json:"file"`
+	Cues   int         `json:"cues"`
+	Issues []sbv.Issue `json:"issues"`
+}
+
+// newLintCmd creates the lint subcommand, which checks a subtitle file
+// without converting it.
+func newLintCmd() *cobra.Command {
+	var (
+		opts       = sbv.DefaultValidationOptions()
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check a subtitle file for timing and layout problems",
+		Long: `Check a subtitle file for cues that end before they start, have no
+		duration, overlap or are out of order, and for cues that are hard to read:
+		too many characters per second, over-long lines, too many lines or no
+		text. A threshold of 0 disables its check. With --lenient, malformed
+		cues are reported as issues too, and cues whose times had to be swapped
+		as end-before-start errors. The command exits with an error if any
+		issue is found.
+
+		Examples:
+		go-sbv-to-srt lint -i captions.sbv
+		go-sbv-to-srt lint -i captions.srt --max-cps 17 --max-line-length 37
+		go-sbv-to-srt lint -i captions.srt --json | jq '.issues[] | select(.severity == "error")'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			subtitles, diagnostics, err := readLintInput()
+			if err != nil {
+				return err
+			}
+
+			report := lintReport{
+				File:   displayPath(inputFile, "<stdin>"),
+				Cues:   len(subtitles),
+				Issues: lintIssues(subtitles, diagnostics, opts),
+			}
+
+			if jsonOutput {
+				err = writeLintJSON(os.Stdout, report)
+			} else {
+				err = writeLintText(os.Stdout, report)
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(report.Issues) > 0 {
+				// The report already explains the failure
+				cmd.SilenceUsage = true
+				return fmt.Errorf("found %d issue(s) in %s", len(report.Issues), report.File)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&inputFile, "input", "i", "", "Input subtitle file, or - for stdin (optional - defaults to stdin)")
+	flags.StringVar(&inputFormat, "from", "", "Input format name (optional - defaults to the input extension, then content detection)")
+	flags.BoolVar(&lenient, "lenient", false, "Skip or repair malformed cues instead of failing, including them in the report")
+	flags.StringVar(&inputEncoding, "encoding", "auto", "Input character encoding: auto, utf-8, utf-8-bom, utf-16le, utf-16be or windows-1252")
+	flags.Float64Var(&opts.MaxCharsPerSecond, "max-cps", opts.MaxCharsPerSecond, "Highest reading speed in characters per second")
+	flags.IntVar(&opts.MaxLineLength, "max-line-length", opts.MaxLineLength, "Most characters allowed on one line")
+	flags.IntVar(&opts.MaxLines, "max-lines", opts.MaxLines, "Most lines allowed in one cue")
+	flags.BoolVar(&jsonOutput, "json", false, "Write the report as JSON")
+
+	return cmd
+}
+
+// readLintInput parses the file or standard input given on the command line.
+func readLintInput() ([]sbv.Subtitle, []sbv.Diagnostic, error) {
+	if inputFile == "" || inputFile == stdio {
+		if inputFile == "" && isTerminal(os.Stdin) {
+			return nil, nil, fmt.Errorf("no input given: use --input or pipe subtitles to standard input")
+		}
+		reader, err := textReader(os.Stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		format, reader, err := resolveStdinFormat(reader, inputFormat)
+		if err != nil {
+			return nil, nil, fmt.Errorf("input validation failed: %w", err)
+		}
+		return decodeReader(reader, format, lenient)
+	}
+
+	if err := validateInputFile(inputFile); err != nil {
+		return nil, nil, fmt.Errorf("input validation failed: %w", err)
+	}
+	format, err := resolveInputFormat(inputFile, inputFormat)
+	if err != nil {
+		return nil, nil, fmt.Errorf("input validation failed: %w", err)
+	}
+	return decodeFile(inputFile, format, lenient)
+}
+
+// lintIssues validates subtitles and reports the lenient parsing diagnostics
+// as issues ahead of them, as they refer to input lines rather than cues.
+func lintIssues(subtitles []sbv.Subtitle, diagnostics []sbv.Diagnostic, opts sbv.ValidationOptions) []sbv.Issue {
+	var issues []sbv.Issue
+	for _, diagnostic := range diagnostics {
+		issues = append(issues, diagnostic.Issue())
+	}
+	return append(issues, sbv.ValidateWithOptions(subtitles, opts)...)
+}
+
+// writeLintText writes the lint report in a human readable form, one issue
+// per line followed by a summary.
+func writeLintText(w io.Writer, report lintReport) error {
+	errors, warnings := 0, 0
+	for _, issue := range report.Issues {
+		if issue.Severity == sbv.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", report.File, issue); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d cue(s) checked: %d error(s), %d warning(s)\n", report.Cues, errors, warnings)
+	return err
+}
+
+// writeLintJSON writes the lint report as indented JSON.
+func writeLintJSON(w io.Writer, report lintReport) error {
+	if report.Issues == nil {
+		report.Issues = []sbv.Issue{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+func TestWriteLintText(t *testing.T) {
+	report := lintReport{
+		File: "captions.srt",
+		Cues: 4,
+		Issues: []sbv.Issue{
+			{Cue: 1, Kind: sbv.IssueEndBeforeStart, Severity: sbv.SeverityError, Message: "backwards"},
+			{Cue: 3, Kind: sbv.IssueLineLength, Severity: sbv.SeverityWarning, Message: "too long"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeLintText(&buf, report); err != nil {
+		t.Fatalf("writeLintText() unexpected error: %v", err)
+	}
+
+	want := "captions.srt: cue 1: error: backwards [end-before-start]\n" +
+		"captions.srt: cue 3: warning: too long [line-length]\n" +
+		"4 cue(s) checked: 1 error(s), 1 warning(s)\n"
+	if buf.String() != want {
+		t.Errorf("writeLintText() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteLintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLintJSON(&buf, lintReport{File: "clean.sbv", Cues: 2}); err != nil {
+		t.Fatalf("writeLintJSON() unexpected error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeLintJSON() wrote invalid JSON %q: %v", buf.String(), err)
+	}
+	if issues, ok := decoded["issues"].([]any); !ok || len(issues) != 0 {
+		t.Errorf("writeLintJSON() issues = %v, want empty array", decoded["issues"])
+	}
+	if decoded["file"] != "clean.sbv" || decoded["cues"] != float64(2) {
+		t.Errorf("writeLintJSON() = %v", decoded)
+	}
+}
+
+func TestLintIssuesLenient(t *testing.T) {
+	input := "0:00:01.000,0:00:03.000\nFirst\n\n" +
+		"0:00:05.000,0:00:04.000\nBackwards\n\n" +
+		"0:00:99.000,0:00:10.000\nBroken\n\n" +
+		"0:00:06.000,0:00:08.000\nLast\n"
+
+	format, _ := sbv.Lookup("sbv")
+	subtitles, diagnostics, err := decodeReader(strings.NewReader(input), format, true)
+	if err != nil {
+		t.Fatalf("decodeReader() unexpected error: %v", err)
+	}
+
+	issues := lintIssues(subtitles, diagnostics, sbv.ValidationOptions{})
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", issue.Cue, issue.Line, issue.Kind, issue.Severity))
+	}
+	want := []string{"0:4 end-before-start error", "0:7 parse error"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lintIssues() = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeLintText(&buf, lintReport{File: "broken.sbv", Cues: len(subtitles), Issues: issues}); err != nil {
+		t.Fatalf("writeLintText() unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "broken.sbv: line 4: error: end time is before start time (swapped start and end times) [end-before-start]\n") {
+		t.Errorf("writeLintText() wrote %q, want the swapped cue as an error", buf.String())
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+// newShiftCmd creates the shift subcommand, which moves every cue by a
+// constant offset.
+func newShiftCmd() *cobra.Command {
+	var by string
+
+	cmd := &cobra.Command{
+		Use:   "shift",
+		Short: "Move every cue earlier or later by a constant offset",
+		Long: `Move every cue earlier or later by a constant offset. The offset is a
+		Go duration such as 2.5s or -1m30s, or a timestamp such as -00:00:02,500.
+		Cues moved before zero are clamped to zero.
+
+		Examples:
+		go-sbv-to-srt shift -i input.srt -o fixed.srt --by 2.5s
+		go-sbv-to-srt shift -i input.sbv --by=-00:00:01.200
+		cat input.srt | go-sbv-to-srt shift --by 500ms > fixed.srt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			offset, err := parseOffset(by)
+			if err != nil {
+				return fmt.Errorf("invalid --by offset: %w", err)
+			}
+			return runConversion(cmd, func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+				return sbv.Shift(subtitles, offset), nil
+			})
+		},
+	}
+
+	addConversionFlags(cmd)
+	cmd.Flags().StringVar(&by, "by", "", "Offset to add to every cue, negative to move cues earlier (e.g. 2.5s, -1m, -00:00:01,500)")
+	if err := cmd.MarkFlagRequired("by"); err != nil {
+		panic(fmt.Sprintf("Failed to mark flag as required: %v", err))
+	}
+
+	return cmd
+}
+
+// newResyncCmd creates the resync subcommand, which corrects offset and
+// drift with a linear mapping.
+func newResyncCmd() *cobra.Command {
+	var (
+		at    []string
+		scale float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "resync",
+		Short: "Correct offset and drift from two reference points or a scale factor",
+		Long: `Correct captions that drift against the video. Give two reference points
+		with --at, each mapping a time or a cue number (counted from 1) to the time
+		it should have, and every cue is moved along the line through them. Or give
+		--scale to stretch the whole timeline by a constant factor.
+		Cues moved before zero are clamped to zero.
+
+		Examples:
+		go-sbv-to-srt resync -i input.srt -o fixed.srt --at 00:00:05,000=00:00:06,200 --at 01:10:00,000=01:10:04,000
+		go-sbv-to-srt resync -i input.srt -o fixed.srt --at 1=00:00:03,000 --at 412=00:58:10,500
+		go-sbv-to-srt resync -i input.srt -o fixed.srt --scale 1.001`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useScale := cmd.Flags().Changed("scale")
+			switch {
+			case useScale && len(at) > 0:
+				return fmt.Errorf("--scale cannot be combined with --at")
+			case useScale:
+				return runConversion(cmd, func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+					return sbv.Scale(subtitles, scale)
+				})
+			case len(at) != 2:
+				return fmt.Errorf("resync needs exactly two --at reference points or --scale")
+			}
+
+			return runConversion(cmd, func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+				a, err := parseSyncPoint(at[0], subtitles)
+				if err != nil {
+					return nil, err
+				}
+				b, err := parseSyncPoint(at[1], subtitles)
+				if err != nil {
+					return nil, err
+				}
+				return sbv.Resync(subtitles, a, b)
+			})
+		},
+	}
+
+	addConversionFlags(cmd)
+	cmd.Flags().StringArrayVar(&at, "at", nil, "Reference point FROM=TO, where FROM is a time or a cue number (give twice)")
+	cmd.Flags().Float64Var(&scale, "scale", 1, "Multiply every time by this factor instead of using reference points (e.g. 1.001)")
+
+	return cmd
+}
+
+// newFramerateCmd creates the framerate subcommand, which retimes cues for
+// video conformed to another frame rate.
+func newFramerateCmd() *cobra.Command {
+	var (
+		fromFPS string
+		toFPS   string
+		snap    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "framerate",
+		Short: "Retime cues for video conformed to another frame rate",
+		Long: `Retime cues for video conformed from one frame rate to another, such as a
+		23.976 fps film sped up to 25 fps for PAL. Rates may be whole numbers,
+		decimals, fractions such as 30000/1001, or the usual NTSC spellings
+		23.976, 29.97 and 59.94. With --snap, cue boundaries are rounded to the
+		nearest frame of the target rate; without --to-fps cues are only snapped.
+
+		Examples:
+		go-sbv-to-srt framerate -i film.srt -o pal.srt --from-fps 23.976 --to-fps 25
+		go-sbv-to-srt framerate -i pal.srt -o film.srt --from-fps 25 --to-fps 24000/1001 --snap
+		go-sbv-to-srt framerate -i input.srt -o snapped.srt --from-fps 29.97 --snap`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := sbv.ParseFrameRate(fromFPS)
+			if err != nil {
+				return fmt.Errorf("invalid --from-fps: %w", err)
+			}
+			to := from
+			if toFPS != "" {
+				if to, err = sbv.ParseFrameRate(toFPS); err != nil {
+					return fmt.Errorf("invalid --to-fps: %w", err)
+				}
+			} else if !snap {
+				return fmt.Errorf("framerate needs --to-fps, --snap or both")
+			}
+
+			return runConversion(cmd, func(subtitles []sbv.Subtitle) ([]sbv.Subtitle, error) {
+				subtitles, err := sbv.ConvertFrameRate(subtitles, from, to)
+				if err != nil || !snap {
+					return subtitles, err
+				}
+				return sbv.SnapToFrames(subtitles, to)
+			})
+		},
+	}
+
+	addConversionFlags(cmd)
+	cmd.Flags().StringVar(&fromFPS, "from-fps", "", "Frame rate the subtitles were timed for (e.g. 23.976, 25, 30000/1001)")
+	cmd.Flags().StringVar(&toFPS, "to-fps", "", "Frame rate of the conformed video (optional - defaults to --from-fps)")
+	cmd.Flags().BoolVar(&snap, "snap", false, "Round cue boundaries to the nearest frame of the target rate")
+	if err := cmd.MarkFlagRequired("from-fps"); err != nil {
+		panic(fmt.Sprintf("Failed to mark flag as required: %v", err))
+	}
+
+	return cmd
+}
+
+// parseOffset parses a signed offset given as a Go duration (2.5s, -1m30s)
+// or as a timestamp (00:00:02,500, -0:00:01.000).
+func parseOffset(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, ":") {
+		return time.ParseDuration(s)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	offset, err := sbv.ParseTimestamp(strings.TrimLeft(s, "+-"))
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		offset = -offset
+	}
+
+	return offset, nil
+}
+
+// parseSyncPoint parses a FROM=TO reference point. FROM is either a cue
+// number, counted from 1, whose start time is used, or a time.
+func parseSyncPoint(s string, subtitles []sbv.Subtitle) (sbv.SyncPoint, error) {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok {
+		return sbv.SyncPoint{}, fmt.Errorf("invalid reference point %q: expected FROM=TO", s)
+	}
+
+	target, err := parseOffset(to)
+	if err != nil {
+		return sbv.SyncPoint{}, fmt.Errorf("invalid reference point %q: %w", s, err)
+	}
+
+	if cue, err := strconv.Atoi(strings.TrimSpace(from)); err == nil {
+		point, err := sbv.CuePoint(subtitles, cue-1, target)
+		if err != nil {
+			return sbv.SyncPoint{}, fmt.Errorf("invalid reference point %q: %w", s, err)
+		}
+		return point, nil
+	}
+
+	source, err := parseOffset(from)
+	if err != nil {
+		return sbv.SyncPoint{}, fmt.Errorf("invalid reference point %q: %w", s, err)
+	}
+
+	return sbv.SyncPoint{From: source, To: target}, nil
+}
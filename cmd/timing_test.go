@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/un-versed/go-sbv-to-srt/pkg/sbv"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "2.5s", want: 2500 * time.Millisecond},
+		{input: "-1m30s", want: -90 * time.Second},
+		{input: "00:00:02,500", want: 2500 * time.Millisecond},
+		{input: "-0:00:01.200", want: -1200 * time.Millisecond},
+		{input: "+01:00:00,000", want: time.Hour},
+		{input: "soon", wantErr: true},
+		{input: "-00:61:00,000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseOffset(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOffset(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseOffset(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSyncPoint(t *testing.T) {
+	subtitles := []sbv.Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 2 * time.Second},
+		{StartTime: 30 * time.Second, EndTime: 32 * time.Second},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    sbv.SyncPoint
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:  "times",
+			input: "00:00:05,000=00:00:06,200",
+			want:  sbv.SyncPoint{From: 5 * time.Second, To: 6200 * time.Millisecond},
+		},
+		{
+			name:  "cue number",
+			input: "2=31s",
+			want:  sbv.SyncPoint{From: 30 * time.Second, To: 31 * time.Second},
+		},
+		{
+			name:    "cue out of range",
+			input:   "3=31s",
+			wantErr: true,
+			errMsg:  "cue 3 out of range",
+		},
+		{
+			name:    "missing separator",
+			input:   "00:00:05,000",
+			wantErr: true,
+			errMsg:  "expected FROM=TO",
+		},
+		{
+			name:    "bad target",
+			input:   "1=later",
+			wantErr: true,
+			errMsg:  "invalid reference point",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSyncPoint(tt.input, subtitles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyncPoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("parseSyncPoint() error = %v, want error containing %v", err, tt.errMsg)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseSyncPoint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
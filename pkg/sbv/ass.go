@@ -0,0 +1,748 @@
+package sbv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ASSStyle is one style of the styles section of an Advanced SubStation
+// Alpha script. Colours are written as given, in the &HAABBGGRR notation.
+type ASSStyle struct {
+	Name     string
+	FontName string
+	FontSize float64
+
+	PrimaryColour   string
+	SecondaryColour string
+	OutlineColour   string
+	BackColour      string
+
+	Bold      bool
+	Italic    bool
+	Underline bool
+	StrikeOut bool
+
+	// ScaleX and ScaleY stretch the font, in percent.
+	ScaleX float64
+	ScaleY float64
+
+	// Spacing is the extra space between letters, in pixels.
+	Spacing float64
+
+	// Angle rotates the text, in degrees.
+	Angle float64
+
+	// BorderStyle is 1 for an outline and drop shadow, or 3 for an opaque box.
+	BorderStyle int
+	Outline     float64
+	Shadow      float64
+
+	// Alignment places the text like the keys of a numeric keypad: 1 to 3
+	// along the bottom, 4 to 6 in the middle and 7 to 9 along the top.
+	Alignment int
+
+	MarginL int
+	MarginR int
+	MarginV int
+
+	// Encoding is the font character set, 1 for the default.
+	Encoding int
+}
+
+// ASSOptions controls the header of an Advanced SubStation Alpha script.
+type ASSOptions struct {
+	// Title is written to the [Script Info] section.
+	Title string
+
+	// PlayResX and PlayResY are the script resolution that style sizes and
+	// margins refer to. Zero uses 1920x1080.
+	PlayResX int
+	PlayResY int
+
+	// Styles are written to the styles section. Dialogue lines use the first
+	// one. Without styles, DefaultASSStyle is used.
+	Styles []ASSStyle
+
+	// SSA writes a SubStation Alpha v4.00 script (.ssa) instead of
+	// Advanced SubStation Alpha v4.00+ (.ass).
+	SSA bool
+}
+
+// DefaultASSStyle returns the style used when no styles are configured:
+// white Arial with a black outline, centred along the bottom of a 1080p frame.
+func DefaultASSStyle() ASSStyle {
+	return ASSStyle{
+		Name:            "Default",
+		FontName:        "Arial",
+		FontSize:        64,
+		PrimaryColour:   "&H00FFFFFF",
+		SecondaryColour: "&H000000FF",
+		OutlineColour:   "&H00000000",
+		BackColour:      "&H80000000",
+		ScaleX:          100,
+		ScaleY:          100,
+		BorderStyle:     1,
+		Outline:         3,
+		Shadow:          1,
+		Alignment:       2,
+		MarginL:         60,
+		MarginR:         60,
+		MarginV:         50,
+		Encoding:        1,
+	}
+}
+
+// Style and dialogue field names of the two script versions, in the order
+// they are written.
+var (
+	assStyleFormat  = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "OutlineColour", "BackColour", "Bold", "Italic", "Underline", "StrikeOut", "ScaleX", "ScaleY", "Spacing", "Angle", "BorderStyle", "Outline", "Shadow", "Alignment", "MarginL", "MarginR", "MarginV", "Encoding"}
+	ssaStyleFormat  = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "TertiaryColour", "BackColour", "Bold", "Italic", "BorderStyle", "Outline", "Shadow", "Alignment", "MarginL", "MarginR", "MarginV", "AlphaLevel", "Encoding"}
+	assEventsFormat = []string{"Layer", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+	ssaEventsFormat = []string{"Marked", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+)
+
+// withDefaults fills in the resolution and style of options that leave them out.
+func (o ASSOptions) withDefaults() ASSOptions {
+	if o.PlayResX <= 0 || o.PlayResY <= 0 {
+		o.PlayResX, o.PlayResY = 1920, 1080
+	}
+	if len(o.Styles) == 0 {
+		o.Styles = []ASSStyle{DefaultASSStyle()}
+	}
+	return o
+}
+
+// writeASSHeader formats the [Script Info] and styles sections and the start
+// of the [Events] section.
+func writeASSHeader(buf cueBuffer, opts ASSOptions) {
+	scriptType, stylesSection, styleFormat, eventsFormat := "v4.00+", "[V4+ Styles]", assStyleFormat, assEventsFormat
+	if opts.SSA {
+		scriptType, stylesSection, styleFormat, eventsFormat = "v4.00", "[V4 Styles]", ssaStyleFormat, ssaEventsFormat
+	}
+
+	buf.WriteString("[Script Info]\n")
+	buf.WriteString("; Script generated by go-sbv-to-srt\n")
+	buf.WriteString("Title: " + opts.Title + "\n")
+	buf.WriteString("ScriptType: " + scriptType + "\n")
+	buf.WriteString("WrapStyle: 0\n")
+	if !opts.SSA {
+		buf.WriteString("ScaledBorderAndShadow: yes\n")
+	}
+	buf.WriteString("PlayResX: " + strconv.Itoa(opts.PlayResX) + "\n")
+	buf.WriteString("PlayResY: " + strconv.Itoa(opts.PlayResY) + "\n")
+	buf.WriteByte('\n')
+
+	buf.WriteString(stylesSection + "\n")
+	buf.WriteString("Format: " + strings.Join(styleFormat, ", ") + "\n")
+	for _, style := range opts.Styles {
+		values := make([]string, len(styleFormat))
+		for i, name := range styleFormat {
+			values[i] = style.field(name, opts.SSA)
+		}
+		buf.WriteString("Style: " + strings.Join(values, ",") + "\n")
+	}
+	buf.WriteByte('\n')
+
+	buf.WriteString("[Events]\n")
+	buf.WriteString("Format: " + strings.Join(eventsFormat, ", ") + "\n")
+}
+
+// writeASSCue formats a single Dialogue line in the first style of opts.
+func writeASSCue(buf cueBuffer, subtitle Subtitle, opts ASSOptions) {
+	marked := "0"
+	if opts.SSA {
+		marked = "Marked=0"
+	}
+
+	// Dialogue: Layer,Start,End,Style,Name,MarginL,MarginR,MarginV,Effect,Text
+	buf.WriteString("Dialogue: " + marked + ",")
+	buf.WriteString(formatASSTime(subtitle.StartTime))
+	buf.WriteByte(',')
+	buf.WriteString(formatASSTime(subtitle.EndTime))
+	buf.WriteString("," + opts.Styles[0].Name + ",,0,0,0,,")
+	buf.WriteString(assText(subtitle.Text))
+	buf.WriteByte('\n')
+}
+
+// formatASSTime formats a time.Duration to ASS timestamp format (H:MM:SS.cc),
+// rounded to the nearest centisecond.
+func formatASSTime(duration time.Duration) string {
+	centiseconds := int64((max(duration, 0) + 5*time.Millisecond) / (10 * time.Millisecond))
+	hours := centiseconds / 360000
+	minutes := centiseconds / 6000 % 60
+	seconds := centiseconds / 100 % 60
+	return fmt.Sprintf("%d:%02d:%02d.%02d", hours, minutes, seconds, centiseconds%100)
+}
+
+// htmlStylePattern matches the basic markup tags of SRT and WebVTT text.
+var htmlStylePattern = regexp.MustCompile(`(?i)<(/?)([ibus])>`)
+
+// assEscaper keeps cue text from being read as override blocks or escape
+// sequences, which ASS has no standard way to escape. The mapping is lossy:
+// braces become the fullwidth ｛ and ｝, and the backslash of \N, \n and \h
+// becomes the fullwidth ＼, so they show as text in every renderer but do not
+// read back as the original characters. A backslash right before an override
+// block that assText adds for markup becomes ＼ too. Other backslashes are
+// kept, as renderers show them as written.
+var assEscaper = strings.NewReplacer(
+	"{", "\uff5b",
+	"}", "\uff5d",
+	`\N`, "\uff3cN",
+	`\n`, "\uff3cn",
+	`\h`, "\uff3ch",
+)
+
+// assText converts cue text to a Dialogue text field: line breaks become \N,
+// basic markup such as <i> becomes the matching override tag and other markup
+// tags are removed. Text that would read as an override block or escape
+// sequence is replaced as described at assEscaper.
+func assText(text string) string {
+	text = markupTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		if htmlStylePattern.MatchString(tag) {
+			return tag
+		}
+		return ""
+	})
+	text = assEscaper.Replace(text)
+	text = htmlStylePattern.ReplaceAllStringFunc(text, func(tag string) string {
+		match := htmlStylePattern.FindStringSubmatch(tag)
+		state := "1"
+		if match[1] == "/" {
+			state = "0"
+		}
+		return `{\` + strings.ToLower(match[2]) + state + "}"
+	})
+	// Braces from the text are escaped, so any \{ left is a backslash before a tag
+	text = strings.ReplaceAll(text, `\{`, "\uff3c{")
+	return strings.ReplaceAll(text, "\n", `\N`)
+}
+
+// field returns the value of the named style field as written in a script.
+// SSA scripts have no underline or strike-out and number the alignment
+// differently.
+func (s ASSStyle) field(name string, ssa bool) string {
+	switch strings.ToLower(name) {
+	case "name":
+		return s.Name
+	case "fontname":
+		return s.FontName
+	case "fontsize":
+		return formatASSNumber(s.FontSize)
+	case "primarycolour":
+		return s.PrimaryColour
+	case "secondarycolour":
+		return s.SecondaryColour
+	case "outlinecolour", "tertiarycolour":
+		return s.OutlineColour
+	case "backcolour":
+		return s.BackColour
+	case "bold":
+		return formatASSBool(s.Bold)
+	case "italic":
+		return formatASSBool(s.Italic)
+	case "underline":
+		return formatASSBool(s.Underline)
+	case "strikeout":
+		return formatASSBool(s.StrikeOut)
+	case "scalex":
+		return formatASSNumber(s.ScaleX)
+	case "scaley":
+		return formatASSNumber(s.ScaleY)
+	case "spacing":
+		return formatASSNumber(s.Spacing)
+	case "angle":
+		return formatASSNumber(s.Angle)
+	case "borderstyle":
+		return strconv.Itoa(s.BorderStyle)
+	case "outline":
+		return formatASSNumber(s.Outline)
+	case "shadow":
+		return formatASSNumber(s.Shadow)
+	case "alignment":
+		if ssa {
+			return strconv.Itoa(ssaAlignment(s.Alignment))
+		}
+		return strconv.Itoa(s.Alignment)
+	case "marginl":
+		return strconv.Itoa(s.MarginL)
+	case "marginr":
+		return strconv.Itoa(s.MarginR)
+	case "marginv":
+		return strconv.Itoa(s.MarginV)
+	case "alphalevel":
+		return "0"
+	case "encoding":
+		return strconv.Itoa(s.Encoding)
+	}
+	return ""
+}
+
+// setField sets the named style field from its value in a script. Unknown
+// fields are ignored.
+func (s *ASSStyle) setField(name, value string, ssa bool) error {
+	value = strings.TrimSpace(value)
+
+	var err error
+	number := func() float64 {
+		f, parseErr := strconv.ParseFloat(value, 64)
+		if parseErr != nil && err == nil {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return f
+	}
+	integer := func() int {
+		return int(number())
+	}
+
+	switch strings.ToLower(name) {
+	case "name":
+		s.Name = value
+	case "fontname":
+		s.FontName = value
+	case "fontsize":
+		s.FontSize = number()
+	case "primarycolour":
+		s.PrimaryColour = value
+	case "secondarycolour":
+		s.SecondaryColour = value
+	case "outlinecolour", "tertiarycolour":
+		s.OutlineColour = value
+	case "backcolour":
+		s.BackColour = value
+	case "bold":
+		s.Bold = number() != 0
+	case "italic":
+		s.Italic = number() != 0
+	case "underline":
+		s.Underline = number() != 0
+	case "strikeout":
+		s.StrikeOut = number() != 0
+	case "scalex":
+		s.ScaleX = number()
+	case "scaley":
+		s.ScaleY = number()
+	case "spacing":
+		s.Spacing = number()
+	case "angle":
+		s.Angle = number()
+	case "borderstyle":
+		s.BorderStyle = integer()
+	case "outline":
+		s.Outline = number()
+	case "shadow":
+		s.Shadow = number()
+	case "alignment":
+		s.Alignment = integer()
+		if ssa {
+			s.Alignment = numpadAlignment(s.Alignment)
+		}
+	case "marginl":
+		s.MarginL = integer()
+	case "marginr":
+		s.MarginR = integer()
+	case "marginv":
+		s.MarginV = integer()
+	case "encoding":
+		s.Encoding = integer()
+	}
+	return err
+}
+
+// ssaAlignment converts numeric keypad alignment to SubStation Alpha's, which
+// adds 4 for the top row and 8 for the middle row to the bottom row's 1 to 3.
+func ssaAlignment(alignment int) int {
+	switch {
+	case alignment >= 7 && alignment <= 9:
+		return alignment - 2
+	case alignment >= 4 && alignment <= 6:
+		return alignment + 5
+	}
+	return alignment
+}
+
+// numpadAlignment converts SubStation Alpha alignment to numeric keypad alignment.
+func numpadAlignment(alignment int) int {
+	switch {
+	case alignment >= 5 && alignment <= 7:
+		return alignment + 2
+	case alignment >= 9 && alignment <= 11:
+		return alignment - 5
+	}
+	return alignment
+}
+
+// formatASSNumber formats a style number without needless decimals.
+func formatASSNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatASSBool formats a style flag, which scripts write as -1 for true.
+func formatASSBool(b bool) string {
+	if b {
+		return "-1"
+	}
+	return "0"
+}
+
+// ParseASSStyles reads the script resolution and styles from an ASS or SSA
+// script or a fragment of one, such as a style file exported from a
+// subtitle editor. Style lines are read using the preceding Format line,
+// or the standard field order of the section without one. Fields a style
+// leaves out, such as the scaling SSA scripts do not have, are taken from
+// DefaultASSStyle.
+func ParseASSStyles(reader io.Reader) (ASSOptions, error) {
+	var (
+		opts    ASSOptions
+		section string
+		format  []string
+		lineNo  int
+	)
+
+	scanner := bufio.NewScanner(autoTextReader(reader))
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			format = nil
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		ssa := section == "[v4 styles]"
+
+		switch strings.ToLower(key) {
+		case "playresx":
+			opts.PlayResX, _ = strconv.Atoi(value)
+		case "playresy":
+			opts.PlayResY, _ = strconv.Atoi(value)
+		case "scripttype":
+			opts.SSA = strings.EqualFold(value, "v4.00")
+		case "format":
+			if section != "[events]" {
+				format = splitASSFields(value, -1)
+			}
+		case "style":
+			fields := format
+			if fields == nil {
+				fields = assStyleFormat
+				if ssa {
+					fields = ssaStyleFormat
+				}
+			}
+
+			style := DefaultASSStyle()
+			for i, field := range splitASSFields(value, len(fields)) {
+				if err := style.setField(fields[i], field, ssa); err != nil {
+					return ASSOptions{}, fmt.Errorf("style at line %d: %w", lineNo, err)
+				}
+			}
+			opts.Styles = append(opts.Styles, style)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ASSOptions{}, fmt.Errorf("error reading input: %w", err)
+	}
+	if len(opts.Styles) == 0 {
+		return ASSOptions{}, fmt.Errorf("no styles found")
+	}
+
+	return opts, nil
+}
+
+// splitASSFields splits a comma-separated field list into at most n trimmed
+// fields, the last of which keeps any further commas. A negative n splits at
+// every comma.
+func splitASSFields(value string, n int) []string {
+	fields := strings.SplitN(value, ",", n)
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// ASSScanner reads the Dialogue lines of an ASS or SSA script one at a time,
+// in constant memory. Fields are read in the order declared by the Format
+// line of the [Events] section. Comment lines and drawings are skipped.
+type ASSScanner struct {
+	// Lenient skips malformed Dialogue lines instead of failing, recording
+	// each problem as a Diagnostic.
+	Lenient bool
+
+	// StripTags removes override tags such as {\i1} from the text. By
+	// default italic, bold, underline and strike-out tags become <i>, <b>,
+	// <u> and <s> markup and all other tags are removed.
+	StripTags bool
+
+	lines       lineReader
+	section     string
+	format      []string
+	diagnostics []Diagnostic
+}
+
+// NewASSScanner creates an ASSScanner reading an ASS or SSA script from reader.
+func NewASSScanner(reader io.Reader) *ASSScanner {
+	return &ASSScanner{lines: newLineReader(reader)}
+}
+
+// Next returns the cue of the next Dialogue line, or io.EOF when the input is exhausted.
+func (s *ASSScanner) Next() (Subtitle, error) {
+	for {
+		line, ok := s.lines.next()
+		if !ok {
+			return Subtitle{}, s.lines.end()
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			s.section = strings.ToLower(line)
+			continue
+		}
+		if s.section != "[events]" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			s.format = splitASSFields(value, -1)
+			continue
+		case "dialogue":
+		default:
+			continue
+		}
+
+		subtitle, err := s.parseDialogue(line, len(key)+1, value)
+		if err != nil {
+			if !s.Lenient {
+				return Subtitle{}, err
+			}
+			parseErr := err.(*ParseError)
+			s.diagnostics = append(s.diagnostics, Diagnostic{
+				Line:     parseErr.Line,
+				Column:   parseErr.Column,
+				Severity: SeverityError,
+				Text:     line,
+				Message:  parseErr.Err.Error(),
+				Action:   "skipped cue",
+				Err:      parseErr.Err,
+			})
+			continue
+		}
+
+		if subtitle.EndTime < subtitle.StartTime && s.Lenient {
+			subtitle.StartTime, subtitle.EndTime = subtitle.EndTime, subtitle.StartTime
+			s.diagnostics = append(s.diagnostics, Diagnostic{
+				Line:     s.lines.line,
+				Severity: SeverityWarning,
+				Text:     line,
+				Message:  "end time is before start time",
+				Action:   "swapped start and end times",
+				Err:      ErrEndBeforeStart,
+			})
+		}
+
+		// Drawings and lines made only of tags have nothing to show as text
+		if strings.TrimSpace(subtitle.Text) == "" {
+			continue
+		}
+		return subtitle, nil
+	}
+}
+
+// Diagnostics returns the problems found so far in lenient mode.
+func (s *ASSScanner) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// parseDialogue parses the fields of a Dialogue line, whose value starts at
+// the 0-based offset start of line.
+func (s *ASSScanner) parseDialogue(line string, start int, value string) (Subtitle, error) {
+	format := s.format
+	if format == nil {
+		format = assEventsFormat
+	}
+	fail := func(column int, err error) (Subtitle, error) {
+		return Subtitle{}, &ParseError{Line: s.lines.line, Column: column, Raw: line, Err: err}
+	}
+
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) != len(format) {
+		return fail(1, errorf(ErrMalformedLine, "expected %d Dialogue fields, found %d", len(format), len(fields)))
+	}
+
+	var (
+		subtitle         Subtitle
+		hasStart, hasEnd bool
+		hasText          bool
+	)
+	column := start + 1
+	for i, name := range format {
+		field := fields[i]
+		fieldColumn := column + len(field) - len(strings.TrimLeft(field, " \t"))
+		column += len(field) + 1
+
+		var err error
+		switch strings.ToLower(name) {
+		case "start":
+			subtitle.StartTime, err = parseASSTime(strings.TrimSpace(field))
+			hasStart = true
+		case "end":
+			subtitle.EndTime, err = parseASSTime(strings.TrimSpace(field))
+			hasEnd = true
+		case "text":
+			subtitle.Text = assMarkup(field, s.StripTags)
+			hasText = true
+		}
+		if err != nil {
+			return fail(fieldColumn, fmt.Errorf("failed to parse %s time: %w", strings.ToLower(name), err))
+		}
+	}
+	if !hasStart || !hasEnd || !hasText {
+		return fail(1, errorf(ErrMalformedLine, "events format has no Start, End or Text field"))
+	}
+
+	return subtitle, nil
+}
+
+// parseASSTime parses a time string in format "H:MM:SS.cc". Any number of
+// fractional digits is accepted and read as a decimal fraction of a second.
+func parseASSTime(timeStr string) (time.Duration, error) {
+	clock, fraction, ok := strings.Cut(timeStr, ".")
+	if !ok || fraction == "" {
+		return 0, errorf(ErrBadTime, "invalid time format: %s", timeStr)
+	}
+	for _, r := range fraction {
+		if r < '0' || r > '9' {
+			return 0, errorf(ErrBadTime, "invalid fraction of a second: %s", fraction)
+		}
+	}
+
+	// Reuse the SBV parser with the fraction as milliseconds
+	milliseconds := (fraction + "00")[:3]
+	return NewConverter().parseTime(clock + "." + milliseconds)
+}
+
+// assOverridePattern matches the override tags that map to basic markup,
+// drawing mode, whose text is not shown as words, and \r, which resets the
+// styling to that of the line or of a named style.
+var assOverridePattern = regexp.MustCompile(`^(?:(i|b|u|s|p)(\d*)|r.*)$`)
+
+// assMarkup converts a Dialogue text field to cue text. \N becomes a line
+// break, \n a space and \h a non-breaking space, and \{ and \} are literal
+// braces as in libass. Override blocks are removed; unless strip is set,
+// italic, bold, underline and strike-out tags become <i>, <b>, <u> and <s>
+// markup, closed at the end of the line if still open.
+// A tag without a value, such as \i, toggles its styling, and \r closes
+// every open tag.
+func assMarkup(text string, strip bool) string {
+	var (
+		result  strings.Builder
+		open    []string // markup tags currently open, innermost last
+		drawing bool
+	)
+
+	setTag := func(tag string, on bool) {
+		for i, openTag := range open {
+			if openTag != tag {
+				continue
+			}
+			if !on {
+				// Close the tags opened inside it too, then reopen them
+				for j := len(open) - 1; j >= i; j-- {
+					result.WriteString("</" + open[j] + ">")
+				}
+				open = slices.Delete(open, i, i+1)
+				for _, reopened := range open[i:] {
+					result.WriteString("<" + reopened + ">")
+				}
+			}
+			return
+		}
+		if on {
+			result.WriteString("<" + tag + ">")
+			open = append(open, tag)
+		}
+	}
+
+	for len(text) > 0 {
+		switch {
+		case text[0] == '{':
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				// An unclosed brace is shown as written
+				if !drawing {
+					result.WriteString(text)
+				}
+				text = ""
+				continue
+			}
+			for _, tag := range strings.Split(text[1:end], `\`)[1:] {
+				match := assOverridePattern.FindStringSubmatch(strings.TrimSpace(tag))
+				switch {
+				case match == nil || strip && match[1] != "p":
+				case match[1] == "":
+					for i := len(open) - 1; i >= 0; i-- {
+						result.WriteString("</" + open[i] + ">")
+					}
+					open = nil
+				case match[1] == "p":
+					value, _ := strconv.Atoi(match[2])
+					drawing = value > 0
+				case match[2] == "":
+					setTag(match[1], !slices.Contains(open, match[1]))
+				default:
+					value, _ := strconv.Atoi(match[2])
+					setTag(match[1], value != 0)
+				}
+			}
+			text = text[end+1:]
+		case strings.HasPrefix(text, `\{`), strings.HasPrefix(text, `\}`):
+			if !drawing {
+				result.WriteByte(text[1])
+			}
+			text = text[2:]
+		case strings.HasPrefix(text, `\N`):
+			if !drawing {
+				result.WriteByte('\n')
+			}
+			text = text[2:]
+		case strings.HasPrefix(text, `\n`):
+			if !drawing {
+				result.WriteByte(' ')
+			}
+			text = text[2:]
+		case strings.HasPrefix(text, `\h`):
+			if !drawing {
+				result.WriteString("\u00a0")
+			}
+			text = text[2:]
+		default:
+			if !drawing {
+				result.WriteByte(text[0])
+			}
+			text = text[1:]
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		result.WriteString("</" + open[i] + ">")
+	}
+
+	return result.String()
+}
@@ -0,0 +1,383 @@
+package sbv
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeASS returns subtitles encoded by an ASSEncoder with opts.
+func encodeASS(t *testing.T, subtitles []Subtitle, opts ASSOptions) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := (ASSEncoder{Options: opts}).Encode(&buf, subtitles); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestASSEncoder(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 4*time.Second + 5*time.Millisecond, Text: "First <i>line</i>\nsecond, with comma"},
+		{StartTime: 1*time.Hour + 2*time.Minute + 3*time.Second + 994*time.Millisecond, EndTime: 1*time.Hour + 2*time.Minute + 5*time.Second, Text: "<B>Bold</B>"},
+	}
+
+	got := encodeASS(t, subtitles, ASSOptions{Title: "Episode 1"})
+
+	want := `[Script Info]
+; Script generated by go-sbv-to-srt
+Title: Episode 1
+ScriptType: v4.00+
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+PlayResX: 1920
+PlayResY: 1080
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,64,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:04.01,Default,,0,0,0,,First {\i1}line{\i0}\Nsecond, with comma
+Dialogue: 0,1:02:03.99,1:02:05.00,Default,,0,0,0,,{\b1}Bold{\b0}
+`
+	if got != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestASSEncoderSSA(t *testing.T) {
+	style := DefaultASSStyle()
+	style.Name = "Top"
+	style.Alignment = 8
+	style.Italic = true
+
+	got := encodeASS(t, []Subtitle{{StartTime: 0, EndTime: time.Second, Text: "Hi"}}, ASSOptions{
+		PlayResX: 640,
+		PlayResY: 480,
+		Styles:   []ASSStyle{style},
+		SSA:      true,
+	})
+
+	for _, want := range []string{
+		"ScriptType: v4.00\n",
+		"PlayResX: 640\nPlayResY: 480\n",
+		"[V4 Styles]\nFormat: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, TertiaryColour, BackColour, Bold, Italic, BorderStyle,",
+		"Style: Top,Arial,64,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,-1,1,3,1,6,60,60,50,0,1\n",
+		"Dialogue: Marked=0,0:00:00.00,0:00:01.00,Top,,0,0,0,,Hi\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Encode() with SSA output is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ScaledBorderAndShadow") {
+		t.Errorf("Encode() with SSA output has an ASS-only header:\n%s", got)
+	}
+}
+
+func TestASSWriter(t *testing.T) {
+	subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}}
+
+	var buf bytes.Buffer
+	writer := NewASSWriter(&buf, ASSOptions{})
+	if err := writer.WriteCue(subtitles[0]); err != nil {
+		t.Fatalf("WriteCue() unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if want := encodeASS(t, subtitles, ASSOptions{}); buf.String() != want {
+		t.Errorf("WriteCue() = %q, want %q", buf.String(), want)
+	}
+
+	// An empty script still has its header
+	buf.Reset()
+	writer = NewASSWriter(&buf, ASSOptions{})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "[Script Info]\n") || !strings.HasSuffix(buf.String(), "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n") {
+		t.Errorf("Flush() wrote %q, want an empty script", buf.String())
+	}
+}
+
+func TestParseASSStyles(t *testing.T) {
+	const script = "\xef\xbb\xbf[Script Info]\r\n" +
+		"; comment\r\n" +
+		"ScriptType: v4.00+\r\n" +
+		"PlayResX: 1280\r\n" +
+		"PlayResY: 720\r\n" +
+		"\r\n" +
+		"[V4+ Styles]\r\n" +
+		"Format: Name, Fontsize, Fontname, Bold, Alignment, MarginV, PrimaryColour\r\n" +
+		"Style: Sign, 40, Open Sans, -1, 8, 20, &H0000FFFF\r\n" +
+		"Style: Main,52,Arial,0,2,30,&H00FFFFFF\r\n" +
+		"\r\n" +
+		"[Events]\r\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Main,,0,0,0,,Style: not a style\r\n"
+
+	opts, err := ParseASSStyles(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseASSStyles() unexpected error: %v", err)
+	}
+	if opts.PlayResX != 1280 || opts.PlayResY != 720 || opts.SSA {
+		t.Errorf("ParseASSStyles() resolution = %dx%d, SSA %v, want 1280x720 ASS", opts.PlayResX, opts.PlayResY, opts.SSA)
+	}
+	sign, main := DefaultASSStyle(), DefaultASSStyle()
+	sign.Name, sign.FontSize, sign.FontName, sign.Bold, sign.Alignment, sign.MarginV, sign.PrimaryColour = "Sign", 40, "Open Sans", true, 8, 20, "&H0000FFFF"
+	main.Name, main.FontSize, main.MarginV = "Main", 52, 30
+	want := []ASSStyle{sign, main}
+	if len(opts.Styles) != len(want) {
+		t.Fatalf("ParseASSStyles() found %d styles, want %d", len(opts.Styles), len(want))
+	}
+	for i := range want {
+		if opts.Styles[i] != want[i] {
+			t.Errorf("ParseASSStyles() style %d = %+v, want %+v", i, opts.Styles[i], want[i])
+		}
+	}
+}
+
+func TestParseASSStylesSSA(t *testing.T) {
+	// Written as SSA and read back, the alignment is mapped both ways
+	style := DefaultASSStyle()
+	style.Alignment = 5
+	script := encodeASS(t, nil, ASSOptions{Styles: []ASSStyle{style}, SSA: true})
+
+	opts, err := ParseASSStyles(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseASSStyles() unexpected error: %v", err)
+	}
+	if !opts.SSA || len(opts.Styles) != 1 || opts.Styles[0] != style {
+		t.Errorf("ParseASSStyles() = %+v, want the SSA script's style %+v", opts, style)
+	}
+}
+
+func TestParseASSStylesErrors(t *testing.T) {
+	inputs := map[string]string{
+		"no styles":  "[Script Info]\nTitle: x\n",
+		"bad number": "[V4+ Styles]\nFormat: Name, Fontsize\nStyle: Default, big\n",
+	}
+	for name, input := range inputs {
+		if _, err := ParseASSStyles(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseASSStyles() expected error for %s, got nil", name)
+		}
+	}
+}
+
+func TestASSDecoder(t *testing.T) {
+	// The Format line puts Text before the times and the last field keeps its commas
+	script := `[Script Info]
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname
+Style: Default, Arial
+
+[Events]
+Format: Layer, Style, Start, End, Text
+Dialogue: 0,Default,0:00:01.00,0:00:04.50,{\i1}Hello{\i0}, world\Nsecond line
+Comment: 0,Default,0:00:02.00,0:00:03.00,not shown
+Dialogue: 0,Default,0:00:05.25,0:00:06.00,{\b1\fs40}Bold {\u1}both{\b0} under{\r}
+Dialogue: 0,Default,0:00:06.00,0:00:07.00,{\i1}a{\b1}b{\u1}c{\i0}d
+Dialogue: 0,Default,0:00:07.00,0:00:08.00,{\p1}m 0 0 l 100 0 100 100{\p0}
+Dialogue: 0,Default,1:02:03.004,1:02:04.00,soft\nwrap\hspace
+`
+
+	got, err := ASSDecoder{}.Decode(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+
+	want := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 4*time.Second + 500*time.Millisecond, Text: "<i>Hello</i>, world\nsecond line"},
+		{StartTime: 5*time.Second + 250*time.Millisecond, EndTime: 6 * time.Second, Text: "<b>Bold <u>both</u></b><u> under</u>"},
+		{StartTime: 6 * time.Second, EndTime: 7 * time.Second, Text: "<i>a<b>b<u>c</u></b></i><b><u>d</u></b>"},
+		{StartTime: 1*time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, EndTime: 1*time.Hour + 2*time.Minute + 4*time.Second, Text: "soft wrap\u00a0space"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Decode() returned %d subtitles, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("subtitle %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestASSDecoderStripTags(t *testing.T) {
+	script := "[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\i1}Hello{\\i0} {\\b1}there\n"
+
+	got, err := ASSDecoder{StripTags: true}.Decode(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Text != "Hello there" {
+		t.Errorf("Decode() = %+v, want one cue with text %q", got, "Hello there")
+	}
+}
+
+func TestASSMarkup(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"reset closes every open tag", `{\i1}a{\b1}b{\r}c`, "<i>a<b>b</b></i>c"},
+		{"reset to named style", `{\u1}a{\rAlt}b`, "<u>a</u>b"},
+		{"reset with nothing open", `{\r}a`, "a"},
+		{"bare tag opens", `{\i}a`, "<i>a</i>"},
+		{"bare tag toggles off", `{\b1}a{\b}b`, "<b>a</b>b"},
+		{"bare tags toggle twice", `{\u}a{\u}b{\s}c`, "<u>a</u>b<s>c</s>"},
+		{"reset then bare tag", `{\i1}a{\r\i}b`, "<i>a</i><i>b</i>"},
+		{"other tags ignored", `{\shad2\bord1\be1\pos(1,2)}a`, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assMarkup(tt.text, false); got != tt.want {
+				t.Errorf("assMarkup(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertASSRoundTrip(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 2*time.Second + 500*time.Millisecond, Text: "<i>Italic</i>, with comma\n<b>bold</b>"},
+	}
+	script := encodeASS(t, subtitles, ASSOptions{SSA: true})
+
+	got, err := ASSDecoder{}.Decode(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != subtitles[0] {
+		t.Errorf("Decode() = %+v, want %+v", got, subtitles)
+	}
+}
+
+func TestASSText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"braces become fullwidth", "{not an override}", "｛not an override｝"},
+		{"plain backslashes kept", `C:\Users\Public`, `C:\Users\Public`},
+		{"escape sequences disarmed", `literal \N, \n and \h`, "literal ＼N, ＼n and ＼h"},
+		{"backslash before markup", `a\<i>b</i>`, "a＼{\\i1}b{\\i0}"},
+		{"unsupported tags removed", `<font color="red">red</font> <i>it</i>`, `red {\i1}it{\i0}`},
+		{"markup and line break", "<b>{b}</b>\nnext", `{\b1}｛b｝{\b0}\Nnext`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assText(tt.text); got != tt.want {
+				t.Errorf("assText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASSTextRoundTrip(t *testing.T) {
+	// Escaped characters come back as their fullwidth replacements
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"braces", "{not an override}", "｛not an override｝"},
+		{"backslashes", `C:\Names\hidden`, "C:＼Names＼hidden"},
+		{"escape sequences", `literal \N and \h`, "literal ＼N and ＼h"},
+		{"unsupported tags removed", `<font color="red">red</font> <i>it</i>`, "red <i>it</i>"},
+		{"markup and line break", "<b>{b}</b>\nnext", "<b>｛b｝</b>\nnext"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: tt.text}}
+			script := encodeASS(t, subtitles, ASSOptions{})
+
+			got, err := ASSDecoder{}.Decode(strings.NewReader(script))
+			if err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if len(got) != 1 || got[0].Text != tt.want {
+				t.Errorf("Decode() = %+v, want one cue with text %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASSDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantErr    error
+		wantColumn int
+	}{
+		{
+			name:       "bad start time",
+			input:      "[Events]\nFormat: Start, End, Text\nDialogue: 0:00:xx.00,0:00:02.00,Hi\n",
+			wantErr:    ErrBadTime,
+			wantColumn: 11,
+		},
+		{
+			name:       "missing fields",
+			input:      "[Events]\nFormat: Start, End, Text\nDialogue: 0:00:01.00\n",
+			wantErr:    ErrMalformedLine,
+			wantColumn: 1,
+		},
+		{
+			name:       "format without text",
+			input:      "[Events]\nFormat: Start, End\nDialogue: 0:00:01.00,0:00:02.00\n",
+			wantErr:    ErrMalformedLine,
+			wantColumn: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ASSDecoder{}.Decode(strings.NewReader(tt.input))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Decode() error = %v, want *ParseError", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == ErrMalformedLine && errors.Is(err, ErrBadTimestamp) {
+				t.Errorf("Decode() error = %v, a structural problem matches ErrBadTimestamp", err)
+			}
+			if parseErr.Line != 3 || parseErr.Column != tt.wantColumn {
+				t.Errorf("ParseError at %d:%d, want 3:%d", parseErr.Line, parseErr.Column, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestASSDecoderLenient(t *testing.T) {
+	script := `[Events]
+Format: Start, End, Text
+Dialogue: 0:00:01.00,0:00:02.00,First
+Dialogue: bad,0:00:03.00,Skipped
+Dialogue: 0:00:05.00,0:00:04.00,Swapped
+`
+
+	got, diagnostics, err := ASSDecoder{}.DecodeLenient(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("DecodeLenient() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[1].StartTime != 4*time.Second || got[1].EndTime != 5*time.Second {
+		t.Errorf("DecodeLenient() = %+v, want the first cue and the swapped cue", got)
+	}
+	if len(diagnostics) != 2 || diagnostics[0].Severity != SeverityError || diagnostics[1].Severity != SeverityWarning {
+		t.Errorf("DecodeLenient() diagnostics = %+v, want one error and one warning", diagnostics)
+	}
+}
@@ -0,0 +1,140 @@
+package sbv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// BatchJob is a single file conversion within a batch.
+type BatchJob struct {
+	// Input is the path of the file to read.
+	Input string
+
+	// Output is the path of the file to write.
+	Output string
+}
+
+// BatchResult is the outcome of a BatchJob.
+type BatchResult struct {
+	BatchJob
+
+	// Count is the number of subtitles written.
+	Count int
+
+	// Diagnostics holds the problems found while parsing in lenient mode.
+	Diagnostics []Diagnostic
+
+	// Err is set if the conversion failed, or if it was never started
+	// because the batch was cancelled.
+	Err error
+}
+
+// BatchFunc converts a single job. It returns the number of subtitles written
+// and any diagnostics collected while parsing. It must be safe to call from
+// several goroutines at once.
+type BatchFunc func(ctx context.Context, job BatchJob) (int, []Diagnostic, error)
+
+// ConvertBatch runs convert for every job on a pool of at most workers
+// goroutines. A workers value below 1 uses one worker per CPU.
+//
+// The results are in the same order as jobs, whatever order the conversions
+// finish in. Once ctx is cancelled no further jobs are started; jobs that
+// were not started report the context error. Jobs already running are
+// expected to watch ctx themselves.
+func ConvertBatch(ctx context.Context, jobs []BatchJob, workers int, convert BatchFunc) []BatchResult {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	results := make([]BatchResult, len(jobs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = runBatchJob(ctx, jobs[i], convert)
+			}
+		}()
+	}
+
+	sent := 0
+feed:
+	for sent < len(jobs) {
+		select {
+		case indexes <- sent:
+			sent++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := sent; i < len(jobs); i++ {
+		results[i] = BatchResult{BatchJob: jobs[i], Err: ctx.Err()}
+	}
+
+	return results
+}
+
+// runBatchJob converts a single job unless the batch has been cancelled.
+func runBatchJob(ctx context.Context, job BatchJob, convert BatchFunc) BatchResult {
+	if err := ctx.Err(); err != nil {
+		return BatchResult{BatchJob: job, Err: err}
+	}
+
+	count, diagnostics, err := convert(ctx, job)
+	return BatchResult{BatchJob: job, Count: count, Diagnostics: diagnostics, Err: err}
+}
+
+// ConvertFile is a BatchFunc that picks the input and output formats from
+// the file extensions of the job and parses the input strictly.
+func ConvertFile(ctx context.Context, job BatchJob) (int, []Diagnostic, error) {
+	inFormat, ok := LookupExtension(filepath.Ext(job.Input))
+	if !ok || !inFormat.CanDecode() {
+		return 0, nil, fmt.Errorf("cannot read format of %s", job.Input)
+	}
+	outFormat, ok := LookupExtension(filepath.Ext(job.Output))
+	if !ok || !outFormat.CanEncode() {
+		return 0, nil, fmt.Errorf("cannot write format of %s", job.Output)
+	}
+
+	file, err := os.Open(job.Input)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open file %s: %w", job.Input, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			// Log the error but don't override the main error
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+
+	subtitles, err := inFormat.Decoder.Decode(file)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	err = WriteFileAtomic(job.Output, func(writer io.Writer) error {
+		return outFormat.Encoder.Encode(writer, subtitles)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(subtitles), nil, nil
+}
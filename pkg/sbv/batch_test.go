@@ -0,0 +1,165 @@
+package sbv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConvertBatchOrdering(t *testing.T) {
+	jobs := make([]BatchJob, 20)
+	for i := range jobs {
+		jobs[i] = BatchJob{Input: fmt.Sprintf("in-%02d.sbv", i), Output: fmt.Sprintf("out-%02d.srt", i)}
+	}
+
+	var active, maxActive int32
+	convert := func(ctx context.Context, job BatchJob) (int, []Diagnostic, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+
+		var index int
+		if _, err := fmt.Sscanf(job.Input, "in-%d.sbv", &index); err != nil {
+			return 0, nil, err
+		}
+		// Later jobs finish first
+		time.Sleep(time.Duration(len(jobs)-index) * time.Millisecond)
+		if index%5 == 0 {
+			return 0, nil, fmt.Errorf("job %d failed", index)
+		}
+		return index, nil, nil
+	}
+
+	results := ConvertBatch(context.Background(), jobs, 4, convert)
+
+	if len(results) != len(jobs) {
+		t.Fatalf("ConvertBatch() returned %d results, want %d", len(results), len(jobs))
+	}
+	for i, result := range results {
+		if result.BatchJob != jobs[i] {
+			t.Errorf("results[%d] is for %+v, want %+v", i, result.BatchJob, jobs[i])
+		}
+		if i%5 == 0 {
+			if result.Err == nil {
+				t.Errorf("results[%d].Err = nil, want error", i)
+			}
+			continue
+		}
+		if result.Err != nil || result.Count != i {
+			t.Errorf("results[%d] = (%d, %v), want (%d, nil)", i, result.Count, result.Err, i)
+		}
+	}
+	if maxActive > 4 {
+		t.Errorf("ConvertBatch() ran %d jobs at once, want at most 4", maxActive)
+	}
+}
+
+func TestConvertBatchCancel(t *testing.T) {
+	jobs := make([]BatchJob, 10)
+	for i := range jobs {
+		jobs[i] = BatchJob{Input: fmt.Sprintf("in-%d.sbv", i)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started int32
+	convert := func(ctx context.Context, job BatchJob) (int, []Diagnostic, error) {
+		if atomic.AddInt32(&started, 1) == 1 {
+			cancel()
+		}
+		return 1, nil, nil
+	}
+
+	results := ConvertBatch(ctx, jobs, 1, convert)
+
+	if started != 1 {
+		t.Errorf("ConvertBatch() started %d jobs after cancellation, want 1", started)
+	}
+	if results[0].Err != nil {
+		t.Errorf("results[0].Err = %v, want nil", results[0].Err)
+	}
+	for i, result := range results[1:] {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i+1, result.Err)
+		}
+		if result.BatchJob != jobs[i+1] {
+			t.Errorf("results[%d] is for %+v, want %+v", i+1, result.BatchJob, jobs[i+1])
+		}
+	}
+}
+
+func TestConvertBatchEmpty(t *testing.T) {
+	results := ConvertBatch(context.Background(), nil, 0, ConvertFile)
+	if len(results) != 0 {
+		t.Errorf("ConvertBatch() returned %d results, want 0", len(results))
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.sbv")
+	if err := os.WriteFile(input, []byte("0:00:01.000,0:00:02.000\nHello\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		job      BatchJob
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "sbv to srt",
+			job:      BatchJob{Input: input, Output: filepath.Join(dir, "output.srt")},
+			expected: "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n",
+		},
+		{
+			name:     "sbv to vtt",
+			job:      BatchJob{Input: input, Output: filepath.Join(dir, "output.vtt")},
+			expected: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n\n",
+		},
+		{
+			name:    "unknown output extension",
+			job:     BatchJob{Input: input, Output: filepath.Join(dir, "output.txt")},
+			wantErr: true,
+		},
+		{
+			name:    "missing input",
+			job:     BatchJob{Input: filepath.Join(dir, "missing.sbv"), Output: filepath.Join(dir, "missing.srt")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, _, err := ConvertFile(context.Background(), tt.job)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if count != 1 {
+				t.Errorf("ConvertFile() count = %d, want 1", count)
+			}
+			content, err := os.ReadFile(tt.job.Output)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("ConvertFile() wrote %q, want %q", string(content), tt.expected)
+			}
+		})
+	}
+}
@@ -1,10 +1,11 @@
-// Package sbv provides functionality to convert SBV (YouTube SubViewer) subtitle files to SRT (SubRip) format.
+// Package sbv provides functionality to convert SBV (YouTube SubViewer) subtitle files to SRT (SubRip) format
+// and back.
 package sbv
 
 import (
-	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -43,11 +44,28 @@ type Converter interface {
 
 // DefaultConverter is the standard implementation of the Converter interface.
 type DefaultConverter struct {
+	lenient            bool
+	preserveWhitespace bool
+	lineEnding         LineEnding
+	startIndex         int
+	inputEncoding      Encoding
+	outputEncoding     Encoding
+	timeFormat         TimeFormat
 }
 
-// NewConverter creates a new instance of DefaultConverter.
-func NewConverter() *DefaultConverter {
-	return &DefaultConverter{}
+// NewConverter creates a new instance of DefaultConverter configured by opts.
+// Without options it parses strictly, auto-detects the input encoding and
+// writes UTF-8 with LF line endings, numbering cues from 1.
+func NewConverter(opts ...Option) *DefaultConverter {
+	c := &DefaultConverter{
+		lineEnding:     LF,
+		startIndex:     1,
+		outputEncoding: EncodingUTF8,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // ConvertToSRT converts parsed subtitles to SRT format string.
@@ -56,24 +74,27 @@ func (c *DefaultConverter) ConvertToSRT(subtitles []Subtitle) string {
 	result.Grow(len(subtitles) * 100) // Pre-allocate approximate capacity
 
 	for i, subtitle := range subtitles {
-		// SRT sequence number (1-based)
-		result.WriteString(strconv.Itoa(i + 1))
-		result.WriteByte('\n')
-
-		// SRT timestamp format: HH:MM:SS,mmm --> HH:MM:SS,mmm
-		startTime := c.formatSRTTime(subtitle.StartTime)
-		endTime := c.formatSRTTime(subtitle.EndTime)
-		result.WriteString(startTime)
-		result.WriteString(" --> ")
-		result.WriteString(endTime)
-		result.WriteByte('\n')
-
-		// Subtitle text
-		result.WriteString(subtitle.Text)
-		result.WriteString("\n\n")
+		c.writeSRTCue(&result, c.startIndex+i, subtitle)
 	}
 
-	return result.String()
+	return c.withLineEnding(result.String())
+}
+
+// writeSRTCue formats a single SRT cue with the given sequence number.
+func (c *DefaultConverter) writeSRTCue(buf cueBuffer, index int, subtitle Subtitle) {
+	// SRT sequence number (1-based unless configured otherwise)
+	buf.WriteString(strconv.Itoa(index))
+	buf.WriteByte('\n')
+
+	// SRT timestamp format: HH:MM:SS,mmm --> HH:MM:SS,mmm
+	buf.WriteString(c.formatSRTTime(subtitle.StartTime))
+	buf.WriteString(" --> ")
+	buf.WriteString(c.formatSRTTime(subtitle.EndTime))
+	buf.WriteByte('\n')
+
+	// Subtitle text
+	buf.WriteString(subtitle.Text)
+	buf.WriteString("\n\n")
 }
 
 // ParseFromFile reads and parses an SBV file from the given file path.
@@ -93,118 +114,118 @@ func (c *DefaultConverter) ParseFromFile(filename string) ([]Subtitle, error) {
 }
 
 // ParseFromReader reads and parses SBV content from an io.Reader.
+// With WithLenient, malformed cues are skipped or repaired.
 func (c *DefaultConverter) ParseFromReader(reader io.Reader) ([]Subtitle, error) {
-	var subtitles []Subtitle
-	scanner := bufio.NewScanner(reader)
-
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, strings.TrimSpace(scanner.Text()))
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %w", err)
-	}
-
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
+	scanner := c.newScanner(reader)
+	scanner.Lenient = c.lenient
+	return ReadAll(scanner)
+}
 
-		// Skip empty lines
-		if line == "" {
-			continue
-		}
+// ParseFromReaderLenient reads and parses SBV content from an io.Reader,
+// skipping or repairing malformed cues instead of failing. Every problem is
+// reported as a Diagnostic; the error is only set if the input cannot be read.
+func (c *DefaultConverter) ParseFromReaderLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := c.newScanner(reader)
+	scanner.Lenient = true
 
-		// Check if this line contains timestamps
-		if c.isTimestampLine(line) {
-			subtitle, nextIndex, err := c.parseSubtitleBlock(lines, i)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse subtitle block: %w", err)
-			}
-			subtitles = append(subtitles, subtitle)
-			i = nextIndex - 1 // -1 because the loop will increment
-		}
-	}
-
-	return subtitles, nil
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
 }
 
 // WriteToFile converts subtitles and writes them directly to an SRT file.
 func (c *DefaultConverter) WriteToFile(subtitles []Subtitle, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", filename, err)
-	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			// Log the error but don't override the main error
-			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
-		}
-	}()
-
-	return c.WriteToWriter(subtitles, file)
+	return WriteFileAtomic(filename, func(writer io.Writer) error {
+		return c.WriteToWriter(subtitles, writer)
+	})
 }
 
 // WriteToWriter converts subtitles and writes them to an io.Writer.
+// Each cue is written as soon as it is formatted.
 func (c *DefaultConverter) WriteToWriter(subtitles []Subtitle, writer io.Writer) error {
-	srtContent := c.ConvertToSRT(subtitles)
-	_, err := writer.Write([]byte(srtContent))
-	if err != nil {
-		return fmt.Errorf("failed to write SRT content: %w", err)
+	return writeCues(newSRTWriter(c.textWriter(writer), c), subtitles)
+}
+
+// newScanner creates a Scanner reading SBV content from reader in the
+// converter's input encoding and whitespace handling.
+func (c *DefaultConverter) newScanner(reader io.Reader) *Scanner {
+	scanner := NewScanner(c.textReader(reader))
+	scanner.PreserveWhitespace = c.preserveWhitespace
+	return scanner
+}
+
+// writeCues writes every subtitle to w and flushes it.
+func writeCues(w CueWriter, subtitles []Subtitle) error {
+	for _, subtitle := range subtitles {
+		if err := w.WriteCue(subtitle); err != nil {
+			return err
+		}
 	}
-	return nil
+	return w.Flush()
 }
 
 // formatSRTTime formats a time.Duration to SRT timestamp format (HH:MM:SS,mmm).
+// The millisecond separator follows the converter's time format.
 func (c *DefaultConverter) formatSRTTime(duration time.Duration) string {
-	hours := int(duration.Hours())
-	minutes := int(duration.Minutes()) % 60
-	seconds := int(duration.Seconds()) % 60
-	milliseconds := int(duration.Nanoseconds()/1_000_000) % 1000
-
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", hours, minutes, seconds, milliseconds)
+	separator := c.timeFormat.SRTDecimalSeparator
+	if separator == 0 {
+		separator = ','
+	}
+	hours, minutes, seconds, milliseconds := splitDuration(duration)
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", hours, minutes, seconds, separator, milliseconds)
 }
 
-// isTimestampLine checks if a line contains SBV timestamp format.
-func (c *DefaultConverter) isTimestampLine(line string) bool {
-	// Simple check: contains comma and colon (timestamp indicators)
-	return strings.ContainsRune(line, ',') && strings.ContainsRune(line, ':')
+// splitDuration breaks a duration into clock components. Hours are not
+// wrapped at 24, since subtitle timestamps are offsets rather than times of day.
+func splitDuration(duration time.Duration) (hours, minutes, seconds, milliseconds int64) {
+	totalMilliseconds := duration.Milliseconds()
+
+	hours = totalMilliseconds / int64(time.Hour/time.Millisecond)
+	minutes = totalMilliseconds / int64(time.Minute/time.Millisecond) % 60
+	seconds = totalMilliseconds / int64(time.Second/time.Millisecond) % 60
+	milliseconds = totalMilliseconds % 1000
+
+	return hours, minutes, seconds, milliseconds
 }
 
-// parseSubtitleBlock parses a single subtitle block starting with a timestamp line.
-func (c *DefaultConverter) parseSubtitleBlock(lines []string, startIndex int) (Subtitle, int, error) {
-	if startIndex >= len(lines) {
-		return Subtitle{}, startIndex, fmt.Errorf("start index out of bounds")
+// sbvTiming describes SBV timing lines for the cue scanners.
+func (c *DefaultConverter) sbvTiming() cueTiming {
+	return cueTiming{
+		isTiming: c.isTimestampLine,
+		parse: func(line string) (time.Duration, time.Duration, error) {
+			startTime, endTime, err := c.parseTimestamps(line)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse timestamps: %w", err)
+			}
+			return startTime, endTime, nil
+		},
+		column: c.timestampErrorColumn,
 	}
+}
 
-	timestampLine := lines[startIndex]
-	startTime, endTime, err := c.parseTimestamps(timestampLine)
-	if err != nil {
-		return Subtitle{}, startIndex, fmt.Errorf("failed to parse timestamps: %w", err)
+// timestampErrorColumn returns the 1-based column of the first malformed
+// time in an SBV timestamp line.
+func (c *DefaultConverter) timestampErrorColumn(timestampLine string) int {
+	parts := strings.Split(timestampLine, ",")
+	if len(parts) != 2 {
+		return 1
 	}
-
-	// Read subtitle text (can be multiple lines)
-	var textLines []string
-	currentIndex := startIndex + 1
-
-	for currentIndex < len(lines) && lines[currentIndex] != "" {
-		textLines = append(textLines, lines[currentIndex])
-		currentIndex++
+	if _, err := c.parseTime(strings.TrimSpace(parts[0])); err != nil {
+		return 1
 	}
+	return len(parts[0]) + 2 + len(parts[1]) - len(strings.TrimLeft(parts[1], " \t"))
+}
 
-	text := strings.Join(textLines, "\n")
-
-	return Subtitle{
-		StartTime: startTime,
-		EndTime:   endTime,
-		Text:      text,
-	}, currentIndex, nil
+// isTimestampLine checks if a line contains SBV timestamp format.
+func (c *DefaultConverter) isTimestampLine(line string) bool {
+	// Simple check: contains comma and colon (timestamp indicators)
+	return strings.ContainsRune(line, ',') && strings.ContainsRune(line, ':')
 }
 
 // parseTimestamps parses SBV timestamp format "H:MM:SS.mmm,H:MM:SS.mmm"
 func (c *DefaultConverter) parseTimestamps(timestampLine string) (time.Duration, time.Duration, error) {
 	parts := strings.Split(timestampLine, ",")
 	if len(parts) != 2 {
-		return 0, 0, fmt.Errorf("invalid timestamp format: %s", timestampLine)
+		return 0, 0, errorf(ErrBadTimestamp, "invalid timestamp format: %s", timestampLine)
 	}
 
 	startTime, err := c.parseTime(strings.TrimSpace(parts[0]))
@@ -220,49 +241,53 @@ func (c *DefaultConverter) parseTimestamps(timestampLine string) (time.Duration,
 	return startTime, endTime, nil
 }
 
-// parseTime parses a time string in format "H:MM:SS.mmm"
+// maxHours is the largest hour count that fits in a time.Duration.
+const maxHours = int(math.MaxInt64/int64(time.Hour)) - 1
+
+// parseTime parses a time string in format "H:MM:SS.mmm".
+// The hour count is unbounded up to maxHours, as SBV timestamps are durations.
 func (c *DefaultConverter) parseTime(timeStr string) (time.Duration, error) {
 	parts := strings.Split(timeStr, ":")
 	if len(parts) != 3 {
-		return 0, fmt.Errorf("invalid time format: %s", timeStr)
+		return 0, errorf(ErrBadTime, "invalid time format: %s", timeStr)
 	}
 
 	hours, err := strconv.Atoi(parts[0])
 	if err != nil {
-		return 0, fmt.Errorf("invalid hours: %s", parts[0])
+		return 0, errorf(ErrBadTime, "invalid hours: %s", parts[0])
 	}
-	if hours < 0 || hours > 23 {
-		return 0, fmt.Errorf("hours out of range (0-23): %d", hours)
+	if hours < 0 || hours > maxHours {
+		return 0, errorf(ErrOutOfRange, "hours out of range (0-%d): %d", maxHours, hours)
 	}
 
 	minutes, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return 0, fmt.Errorf("invalid minutes: %s", parts[1])
+		return 0, errorf(ErrBadTime, "invalid minutes: %s", parts[1])
 	}
 	if minutes < 0 || minutes > 59 {
-		return 0, fmt.Errorf("minutes out of range (0-59): %d", minutes)
+		return 0, errorf(ErrOutOfRange, "minutes out of range (0-59): %d", minutes)
 	}
 
 	// Handle seconds and milliseconds
 	secondsParts := strings.Split(parts[2], ".")
 	if len(secondsParts) != 2 {
-		return 0, fmt.Errorf("invalid seconds format: %s", parts[2])
+		return 0, errorf(ErrBadTime, "invalid seconds format: %s", parts[2])
 	}
 
 	seconds, err := strconv.Atoi(secondsParts[0])
 	if err != nil {
-		return 0, fmt.Errorf("invalid seconds: %s", secondsParts[0])
+		return 0, errorf(ErrBadTime, "invalid seconds: %s", secondsParts[0])
 	}
 	if seconds < 0 || seconds > 59 {
-		return 0, fmt.Errorf("seconds out of range (0-59): %d", seconds)
+		return 0, errorf(ErrOutOfRange, "seconds out of range (0-59): %d", seconds)
 	}
 
 	milliseconds, err := strconv.Atoi(secondsParts[1])
 	if err != nil {
-		return 0, fmt.Errorf("invalid milliseconds: %s", secondsParts[1])
+		return 0, errorf(ErrBadTime, "invalid milliseconds: %s", secondsParts[1])
 	}
 	if milliseconds < 0 || milliseconds > 999 {
-		return 0, fmt.Errorf("milliseconds out of range (0-999): %d", milliseconds)
+		return 0, errorf(ErrOutOfRange, "milliseconds out of range (0-999): %d", milliseconds)
 	}
 
 	totalDuration := time.Duration(hours)*time.Hour +
@@ -151,11 +151,39 @@ func TestParseTime(t *testing.T) {
 			wantErr:     true,
 			description: "should reject non-numeric hours",
 		},
+		{
+			name:        "valid time at 24 hours",
+			input:       "24:00:00.000",
+			want:        24 * time.Hour,
+			wantErr:     false,
+			description: "should accept hours beyond a single day",
+		},
+		{
+			name:        "valid time at 99 hours",
+			input:       "99:59:59.999",
+			want:        99*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond,
+			wantErr:     false,
+			description: "should accept the largest two-digit hour timestamp",
+		},
+		{
+			name:        "valid time at 100 hours",
+			input:       "100:00:00.000",
+			want:        100 * time.Hour,
+			wantErr:     false,
+			description: "should accept three-digit hours",
+		},
+		{
+			name:        "valid time at maximum hours",
+			input:       "2562046:59:59.999",
+			want:        2562046*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond,
+			wantErr:     false,
+			description: "should accept the largest hour count that fits in a time.Duration",
+		},
 		{
 			name:        "invalid hours - out of range high",
-			input:       "25:00:30.500",
+			input:       "2562047:00:30.500",
 			wantErr:     true,
-			description: "should reject hours greater than 23",
+			description: "should reject hours that overflow a time.Duration",
 		},
 		{
 			name:        "invalid hours - negative",
@@ -364,6 +392,31 @@ func TestFormatSRTTime(t *testing.T) {
 			duration: 1*time.Hour + 30*time.Minute + 15*time.Second + 500*time.Millisecond,
 			expected: "01:30:15,500",
 		},
+		{
+			name:     "24 hours",
+			duration: 24 * time.Hour,
+			expected: "24:00:00,000",
+		},
+		{
+			name:     "just under 100 hours",
+			duration: 99*time.Hour + 59*time.Minute + 59*time.Second + 999*time.Millisecond,
+			expected: "99:59:59,999",
+		},
+		{
+			name:     "100 hours",
+			duration: 100 * time.Hour,
+			expected: "100:00:00,000",
+		},
+		{
+			name:     "1000 hours and change",
+			duration: 1000*time.Hour + 1*time.Minute + 2*time.Second + 3*time.Millisecond,
+			expected: "1000:01:02,003",
+		},
+		{
+			name:     "sub-millisecond remainder is truncated",
+			duration: 1*time.Second + 999*time.Millisecond + 999*time.Microsecond,
+			expected: "00:00:01,999",
+		},
 	}
 
 	for _, tt := range tests {
@@ -376,6 +429,39 @@ func TestFormatSRTTime(t *testing.T) {
 	}
 }
 
+func TestParseFromReaderLongTimestamps(t *testing.T) {
+	converter := NewConverter()
+
+	sbvContent := `23:59:59.500,24:00:01.000
+Crossing the one day mark
+
+123:45:06.789,123:45:08.000
+Deep into the livestream`
+
+	subtitles, err := converter.ParseFromReader(strings.NewReader(sbvContent))
+	if err != nil {
+		t.Fatalf("ParseFromReader() error: %v", err)
+	}
+
+	srtOutput := converter.ConvertToSRT(subtitles)
+	expected := `1
+23:59:59,500 --> 24:00:01,000
+Crossing the one day mark
+
+2
+123:45:06,789 --> 123:45:08,000
+Deep into the livestream
+
+`
+	if srtOutput != expected {
+		t.Errorf("ConvertToSRT() = %q, want %q", srtOutput, expected)
+	}
+
+	if sbvOutput := converter.ConvertToSBV(subtitles); sbvOutput != sbvContent+"\n" {
+		t.Errorf("ConvertToSBV() = %q, want %q", sbvOutput, sbvContent+"\n")
+	}
+}
+
 func TestFullConversion(t *testing.T) {
 	converter := NewConverter()
 
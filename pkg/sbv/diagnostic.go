@@ -0,0 +1,97 @@
+package sbv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Severity classifies how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityWarning marks a problem that was repaired or ignored without losing cues.
+	SeverityWarning Severity = iota
+	// SeverityError marks a problem that caused a cue to be dropped.
+	SeverityError
+)
+
+// String returns the lower-case name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText encodes the severity as its name, so it reads naturally in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic describes a problem found while parsing in lenient mode.
+type Diagnostic struct {
+	// Line is the 1-based line number of the offending input line.
+	Line int `json:"line"`
+
+	// Column is the 1-based column where the problem starts, or 0 if the
+	// whole line is affected.
+	Column int `json:"column"`
+
+	// Severity tells whether cues were lost because of the problem.
+	Severity Severity `json:"severity"`
+
+	// Text is the offending input line.
+	Text string `json:"text"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Action describes what the parser did about it.
+	Action string `json:"action"`
+
+	// Err is the underlying parse error for skipped cues, or
+	// ErrEndBeforeStart for cues whose times were swapped, usable with
+	// errors.Is. It is nil for other warnings.
+	Err error `json:"-"`
+}
+
+// String formats the diagnostic as "line:column: severity: message (action): text".
+func (d Diagnostic) String() string {
+	position := fmt.Sprintf("%d", d.Line)
+	if d.Column > 0 {
+		position = fmt.Sprintf("%d:%d", d.Line, d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s): %q", position, d.Severity, d.Message, d.Action, d.Text)
+}
+
+// Issue reports the diagnostic as a validation Issue, so lint reports can list
+// parse problems alongside timing and layout problems. Swapped times become an
+// IssueEndBeforeStart error, since the repair hides the cue from Validate;
+// everything else is an IssueParse with the diagnostic's severity.
+func (d Diagnostic) Issue() Issue {
+	issue := Issue{
+		Line:     d.Line,
+		Kind:     IssueParse,
+		Severity: d.Severity,
+		Message:  fmt.Sprintf("%s (%s)", d.Message, d.Action),
+	}
+	if errors.Is(d.Err, ErrEndBeforeStart) {
+		issue.Kind = IssueEndBeforeStart
+		issue.Severity = SeverityError
+	}
+	return issue
+}
+
+// LenientDecoder is implemented by decoders that can skip or repair malformed
+// cues instead of failing, reporting what they did as diagnostics.
+type LenientDecoder interface {
+	// DecodeLenient parses the content of reader, collecting a Diagnostic for
+	// every malformed or suspicious part of the input. The error is only set
+	// when the input itself cannot be read.
+	DecodeLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error)
+}
@@ -0,0 +1,197 @@
+package sbv
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFromReaderLenient(t *testing.T) {
+	converter := NewConverter()
+
+	sbvContent := `stray header text
+
+0:00:01.000,0:00:02.000
+Good cue
+
+0:00:03.000,0:00:xx.000
+Broken end time
+still part of the broken cue
+
+0:00:06.000,0:00:05.000
+Backwards timing
+
+0:00:07.000,0:00:08.000
+
+0:00:09.000,0:00:10.000
+Last cue`
+
+	subtitles, diagnostics, err := converter.ParseFromReaderLenient(strings.NewReader(sbvContent))
+	if err != nil {
+		t.Fatalf("ParseFromReaderLenient() unexpected error: %v", err)
+	}
+
+	wantSubtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 2 * time.Second, Text: "Good cue"},
+		{StartTime: 5 * time.Second, EndTime: 6 * time.Second, Text: "Backwards timing"},
+		{StartTime: 7 * time.Second, EndTime: 8 * time.Second, Text: ""},
+		{StartTime: 9 * time.Second, EndTime: 10 * time.Second, Text: "Last cue"},
+	}
+	if len(subtitles) != len(wantSubtitles) {
+		t.Fatalf("ParseFromReaderLenient() got %d subtitles, want %d: %+v", len(subtitles), len(wantSubtitles), subtitles)
+	}
+	for i := range wantSubtitles {
+		if subtitles[i] != wantSubtitles[i] {
+			t.Errorf("subtitle %d = %+v, want %+v", i, subtitles[i], wantSubtitles[i])
+		}
+	}
+
+	wantDiagnostics := []Diagnostic{
+		{Line: 1, Column: 0, Severity: SeverityWarning, Text: "stray header text", Action: "ignored line"},
+		{Line: 6, Column: 13, Severity: SeverityError, Text: "0:00:03.000,0:00:xx.000", Action: "skipped cue"},
+		{Line: 10, Column: 0, Severity: SeverityWarning, Text: "0:00:06.000,0:00:05.000", Action: "swapped start and end times"},
+		{Line: 13, Column: 0, Severity: SeverityWarning, Text: "0:00:07.000,0:00:08.000", Action: "kept empty cue"},
+	}
+	if len(diagnostics) != len(wantDiagnostics) {
+		t.Fatalf("ParseFromReaderLenient() got %d diagnostics, want %d: %+v", len(diagnostics), len(wantDiagnostics), diagnostics)
+	}
+	for i, want := range wantDiagnostics {
+		got := diagnostics[i]
+		if got.Line != want.Line || got.Column != want.Column || got.Severity != want.Severity ||
+			got.Text != want.Text || got.Action != want.Action {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, got, want)
+		}
+		if got.Message == "" {
+			t.Errorf("diagnostic %d has no message", i)
+		}
+	}
+}
+
+func TestDiagnosticIssue(t *testing.T) {
+	tests := []struct {
+		name       string
+		diagnostic Diagnostic
+		want       Issue
+	}{
+		{
+			name:       "swapped times are an error",
+			diagnostic: Diagnostic{Line: 10, Severity: SeverityWarning, Message: "end time is before start time", Action: "swapped start and end times", Err: ErrEndBeforeStart},
+			want:       Issue{Line: 10, Kind: IssueEndBeforeStart, Severity: SeverityError, Message: "end time is before start time (swapped start and end times)"},
+		},
+		{
+			name:       "skipped cue",
+			diagnostic: Diagnostic{Line: 6, Severity: SeverityError, Message: "invalid time", Action: "skipped cue", Err: ErrBadTime},
+			want:       Issue{Line: 6, Kind: IssueParse, Severity: SeverityError, Message: "invalid time (skipped cue)"},
+		},
+		{
+			name:       "warning",
+			diagnostic: Diagnostic{Line: 1, Severity: SeverityWarning, Message: "text outside of a cue", Action: "ignored line"},
+			want:       Issue{Line: 1, Kind: IssueParse, Severity: SeverityWarning, Message: "text outside of a cue (ignored line)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.diagnostic.Issue(); got != tt.want {
+				t.Errorf("Issue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	issue := Issue{Line: 6, Kind: IssueParse, Severity: SeverityError, Message: "invalid time (skipped cue)"}
+	if want := "line 6: error: invalid time (skipped cue) [parse]"; issue.String() != want {
+		t.Errorf("String() = %q, want %q", issue.String(), want)
+	}
+}
+
+func TestParseFromReaderStrictStillFails(t *testing.T) {
+	converter := NewConverter()
+
+	_, err := converter.ParseFromReader(strings.NewReader("0:00:03.000,0:00:xx.000\nBroken\n"))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse subtitle block") {
+		t.Errorf("ParseFromReader() error = %v, want subtitle block error", err)
+	}
+}
+
+func TestParseSRTFromReaderLenient(t *testing.T) {
+	converter := NewConverter()
+
+	srtContent := `1
+00:00:01,000 --> 00:00:02,000
+Good cue
+
+2
+00:00:03,000 --> 00:0x:04,000
+Broken end time
+
+3
+00:00:05,000 --> 00:00:06,000
+Another good cue
+`
+
+	subtitles, diagnostics, err := converter.ParseSRTFromReaderLenient(strings.NewReader(srtContent))
+	if err != nil {
+		t.Fatalf("ParseSRTFromReaderLenient() unexpected error: %v", err)
+	}
+	if len(subtitles) != 2 || subtitles[1].Text != "Another good cue" {
+		t.Errorf("ParseSRTFromReaderLenient() subtitles = %+v", subtitles)
+	}
+
+	// Sequence numbers are expected and must not be reported
+	if len(diagnostics) != 1 {
+		t.Fatalf("ParseSRTFromReaderLenient() diagnostics = %+v, want exactly one", diagnostics)
+	}
+	if d := diagnostics[0]; d.Line != 6 || d.Column != 18 || d.Severity != SeverityError {
+		t.Errorf("diagnostic = %+v, want line 6, column 18, error", d)
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		want     string
+	}{
+		{SeverityWarning, "warning"},
+		{SeverityError, "error"},
+		{Severity(7), "severity(7)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.severity.String(); got != tt.want {
+			t.Errorf("Severity(%d).String() = %q, want %q", int(tt.severity), got, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{
+		Line:     6,
+		Column:   13,
+		Severity: SeverityError,
+		Text:     "0:00:03.000,0:00:xx.000",
+		Message:  "invalid minutes: xx",
+		Action:   "skipped cue",
+	}
+
+	want := `6:13: error: invalid minutes: xx (skipped cue): "0:00:03.000,0:00:xx.000"`
+	if got := d.String(); got != want {
+		t.Errorf("Diagnostic.String() = %q, want %q", got, want)
+	}
+
+	d.Column = 0
+	if got := d.String(); !strings.HasPrefix(got, "6: error:") {
+		t.Errorf("Diagnostic.String() without column = %q", got)
+	}
+}
+
+func TestBuiltinDecodersAreLenient(t *testing.T) {
+	for _, name := range []string{"sbv", "srt", "ass", "ssa", "ttml"} {
+		format, ok := Lookup(name)
+		if !ok {
+			t.Fatalf("Lookup(%q) found nothing", name)
+		}
+		if _, ok := format.Decoder.(LenientDecoder); !ok {
+			t.Errorf("%s decoder does not implement LenientDecoder", name)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package sbv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding of subtitle files.
+type Encoding int
+
+const (
+	// EncodingAuto detects the encoding of input from a byte order mark or,
+	// without one, from the content. It is not valid for output.
+	EncodingAuto Encoding = iota
+	// EncodingUTF8 is UTF-8 without a byte order mark.
+	EncodingUTF8
+	// EncodingUTF8BOM is UTF-8 starting with a byte order mark, which some
+	// Windows players need to recognise UTF-8.
+	EncodingUTF8BOM
+	// EncodingUTF16LE is little-endian UTF-16 with a byte order mark.
+	EncodingUTF16LE
+	// EncodingUTF16BE is big-endian UTF-16 with a byte order mark.
+	EncodingUTF16BE
+	// EncodingWindows1252 is the Western European Windows code page, used by
+	// many older subtitle files. Characters it cannot represent are written
+	// as '?'.
+	EncodingWindows1252
+)
+
+// encodingNames lists the encoding names used on the command line, canonical
+// name first.
+var encodingNames = []struct {
+	names    []string
+	encoding Encoding
+}{
+	{[]string{"auto"}, EncodingAuto},
+	{[]string{"utf-8", "utf8"}, EncodingUTF8},
+	{[]string{"utf-8-bom", "utf8-bom"}, EncodingUTF8BOM},
+	{[]string{"utf-16le", "utf16le"}, EncodingUTF16LE},
+	{[]string{"utf-16be", "utf16be"}, EncodingUTF16BE},
+	{[]string{"windows-1252", "cp1252"}, EncodingWindows1252},
+}
+
+// ParseEncoding returns the encoding with the given name: auto, utf-8,
+// utf-8-bom, utf-16le, utf-16be or windows-1252.
+func ParseEncoding(name string) (Encoding, error) {
+	name = strings.ToLower(name)
+	for _, entry := range encodingNames {
+		for _, n := range entry.names {
+			if n == name {
+				return entry.encoding, nil
+			}
+		}
+	}
+	return EncodingAuto, fmt.Errorf("unknown encoding: %s (must be one of auto, utf-8, utf-8-bom, utf-16le, utf-16be, windows-1252)", name)
+}
+
+// String returns the canonical name of the encoding.
+func (e Encoding) String() string {
+	for _, entry := range encodingNames {
+		if entry.encoding == e {
+			return entry.names[0]
+		}
+	}
+	return fmt.Sprintf("encoding(%d)", int(e))
+}
+
+// Byte order marks of the Unicode encodings.
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// encodingSniffSize is the number of leading bytes examined by DetectEncoding
+// when reading.
+const encodingSniffSize = 4096
+
+// DetectEncoding guesses the encoding of text from its leading bytes. A byte
+// order mark is trusted first. Without one, text with many zero bytes in
+// alternate positions is taken as UTF-16, valid UTF-8 as UTF-8, and anything
+// else as Windows-1252. Plain ASCII is reported as UTF-8. TextReader only
+// examines the first 4096 bytes, and settles input that starts with plain
+// ASCII at its first non-ASCII character instead.
+func DetectEncoding(head []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	// Subtitle text is mostly ASCII, which UTF-16 pads with a zero byte
+	evenZeros, oddZeros := 0, 0
+	for i, b := range head {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case pairs > 0 && oddZeros > pairs/2 && evenZeros == 0:
+		return EncodingUTF16LE
+	case pairs > 0 && evenZeros > pairs/2 && oddZeros == 0:
+		return EncodingUTF16BE
+	}
+
+	if utf8.Valid(trimPartialRune(head)) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of head,
+// which may have been cut off in the middle of a character.
+func trimPartialRune(head []byte) []byte {
+	for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(head[i]) {
+			if !utf8.FullRune(head[i:]) {
+				return head[:i]
+			}
+			break
+		}
+	}
+	return head
+}
+
+// TextReader decodes text in a given or detected encoding to UTF-8, without
+// a byte order mark.
+type TextReader struct {
+	src      *bufio.Reader
+	encoding Encoding
+	started  bool
+	ascii    bool // detected as UTF-8 with no non-ASCII byte seen yet
+	err      error
+
+	raw     []byte // undecoded bytes left over from the last read
+	decoded []byte // decoded bytes not yet returned
+}
+
+// NewTextReader returns a reader that decodes reader from encoding to UTF-8.
+// With EncodingAuto the encoding is detected from the first 4096 bytes of the
+// input; if they are plain ASCII, the first non-ASCII character later on
+// decides between UTF-8 and Windows-1252. A leading byte order mark is always
+// removed.
+func NewTextReader(reader io.Reader, encoding Encoding) *TextReader {
+	return &TextReader{src: bufio.NewReaderSize(reader, encodingSniffSize), encoding: encoding}
+}
+
+// Encoding returns the encoding being decoded. For EncodingAuto it is only
+// known after the first Read.
+func (r *TextReader) Encoding() Encoding {
+	return r.encoding
+}
+
+// Read reads decoded UTF-8 text into p.
+func (r *TextReader) Read(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.start()
+	}
+
+	if r.ascii {
+		return r.readASCII(p)
+	}
+
+	// UTF-8 needs no decoding once the byte order mark is gone
+	if r.encoding == EncodingUTF8 || r.encoding == EncodingUTF8BOM {
+		return r.src.Read(p)
+	}
+
+	for len(r.decoded) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill(len(p))
+	}
+
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+	return n, nil
+}
+
+// start detects the encoding if needed and skips the byte order mark. A read
+// error is left for the following reads to return, after the bytes read
+// before it.
+func (r *TextReader) start() {
+	head, _ := r.src.Peek(encodingSniffSize)
+
+	if r.encoding == EncodingAuto {
+		r.encoding = DetectEncoding(head)
+		r.ascii = r.encoding == EncodingUTF8 && !slices.ContainsFunc(head, func(b byte) bool {
+			return b >= utf8.RuneSelf
+		})
+	}
+
+	var bom []byte
+	switch r.encoding {
+	case EncodingUTF8, EncodingUTF8BOM:
+		bom = bomUTF8
+	case EncodingUTF16LE:
+		bom = bomUTF16LE
+	case EncodingUTF16BE:
+		bom = bomUTF16BE
+	}
+	if bom != nil && bytes.HasPrefix(head, bom) {
+		// The mark is buffered, so discarding it cannot fail
+		_, _ = r.src.Discard(len(bom))
+	}
+}
+
+// readASCII reads input detected as UTF-8 from plain ASCII. The first
+// non-ASCII character settles the encoding: valid UTF-8 keeps it, anything
+// else switches to Windows-1252, which reads the ASCII before it the same way.
+func (r *TextReader) readASCII(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	i := 0
+	for i < n && p[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == n {
+		return n, err
+	}
+	r.ascii = false
+
+	// The rest of a character cut off by the read is still buffered
+	head := p[i:n:n]
+	if !utf8.FullRune(head) {
+		more, _ := r.src.Peek(utf8.UTFMax - len(head))
+		head = append(head, more...)
+	}
+	if rn, size := utf8.DecodeRune(head); rn != utf8.RuneError || size > 1 {
+		return n, err
+	}
+
+	r.encoding = EncodingWindows1252
+	for _, b := range p[i:n] {
+		r.decoded = utf8.AppendRune(r.decoded, windows1252Rune(b))
+	}
+	r.err = err
+	if i > 0 {
+		return i, nil
+	}
+	return r.Read(p)
+}
+
+// fill reads up to size more bytes from the source and decodes them.
+func (r *TextReader) fill(size int) {
+	buf := make([]byte, max(size, 2))
+	n, err := r.src.Read(buf)
+	r.raw = append(r.raw, buf[:n]...)
+	if err != nil {
+		r.err = err
+	}
+
+	switch r.encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		r.decodeUTF16(r.err != nil)
+	default:
+		for _, b := range r.raw {
+			r.decoded = utf8.AppendRune(r.decoded, windows1252Rune(b))
+		}
+		r.raw = r.raw[:0]
+	}
+}
+
+// decodeUTF16 decodes the complete code units in raw, keeping a trailing odd
+// byte or unpaired high surrogate for the next read unless at the end.
+func (r *TextReader) decodeUTF16(atEnd bool) {
+	order := utf16Order(r.encoding)
+
+	units := make([]uint16, 0, len(r.raw)/2)
+	for i := 0; i+1 < len(r.raw); i += 2 {
+		units = append(units, order(r.raw[i:]))
+	}
+	used := len(units) * 2
+
+	if !atEnd && len(units) > 0 && utf16.IsSurrogate(rune(units[len(units)-1])) && units[len(units)-1] < 0xDC00 {
+		units = units[:len(units)-1]
+		used -= 2
+	}
+	for _, rn := range utf16.Decode(units) {
+		r.decoded = utf8.AppendRune(r.decoded, rn)
+	}
+
+	r.raw = append(r.raw[:0], r.raw[used:]...)
+	if atEnd && len(r.raw) > 0 {
+		r.decoded = utf8.AppendRune(r.decoded, utf8.RuneError)
+		r.raw = r.raw[:0]
+	}
+}
+
+// utf16Order returns a function reading one code unit in the byte order of encoding.
+func utf16Order(encoding Encoding) func(b []byte) uint16 {
+	if encoding == EncodingUTF16BE {
+		return func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) }
+	}
+	return func(b []byte) uint16 { return uint16(b[1])<<8 | uint16(b[0]) }
+}
+
+// TextWriter encodes UTF-8 text to a given encoding, starting with a byte
+// order mark for the encodings that have one.
+type TextWriter struct {
+	dst      io.Writer
+	encoding Encoding
+	started  bool
+	pending  []byte // an incomplete UTF-8 sequence from the last write
+}
+
+// NewTextWriter returns a writer that encodes UTF-8 text to encoding before
+// writing it to writer. EncodingAuto writes UTF-8 unchanged.
+func NewTextWriter(writer io.Writer, encoding Encoding) *TextWriter {
+	return &TextWriter{dst: writer, encoding: encoding}
+}
+
+// Write encodes p and writes it to the underlying writer. A character split
+// across two writes is encoded once it is complete.
+func (w *TextWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		var bom []byte
+		switch w.encoding {
+		case EncodingUTF8BOM:
+			bom = bomUTF8
+		case EncodingUTF16LE:
+			bom = bomUTF16LE
+		case EncodingUTF16BE:
+			bom = bomUTF16BE
+		}
+		if _, err := w.dst.Write(bom); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.encoding == EncodingAuto || w.encoding == EncodingUTF8 || w.encoding == EncodingUTF8BOM {
+		return w.dst.Write(p)
+	}
+
+	text := append(w.pending, p...)
+	encoded := make([]byte, 0, len(text)*2)
+	for len(text) > 0 {
+		if !utf8.FullRune(text) {
+			break
+		}
+		rn, size := utf8.DecodeRune(text)
+		text = text[size:]
+		encoded = w.appendRune(encoded, rn)
+	}
+	w.pending = append(w.pending[:0:0], text...)
+
+	if _, err := w.dst.Write(encoded); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// appendRune appends rn to buf in the writer's encoding.
+func (w *TextWriter) appendRune(buf []byte, rn rune) []byte {
+	switch w.encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		units := []uint16{uint16(rn)}
+		if r1, r2 := utf16.EncodeRune(rn); r1 != utf8.RuneError {
+			units = []uint16{uint16(r1), uint16(r2)}
+		}
+		for _, unit := range units {
+			if w.encoding == EncodingUTF16BE {
+				buf = append(buf, byte(unit>>8), byte(unit))
+			} else {
+				buf = append(buf, byte(unit), byte(unit>>8))
+			}
+		}
+		return buf
+	default:
+		return append(buf, windows1252Byte(rn))
+	}
+}
+
+// autoTextReader returns reader unchanged if it is a TextReader, as its
+// input has already been decoded from a declared or detected encoding, and
+// otherwise a TextReader detecting the encoding of reader.
+func autoTextReader(reader io.Reader) io.Reader {
+	if _, ok := reader.(*TextReader); ok {
+		return reader
+	}
+	return NewTextReader(reader, EncodingAuto)
+}
+
+// windows1252High maps the bytes 0x80 to 0x9F of Windows-1252, where it
+// differs from Latin-1, to runes. Unassigned bytes map to themselves.
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// windows1252Rune returns the rune encoded by b in Windows-1252.
+func windows1252Rune(b byte) rune {
+	if b >= 0x80 && b <= 0x9F {
+		return windows1252High[b-0x80]
+	}
+	return rune(b)
+}
+
+// windows1252Byte returns the Windows-1252 byte for rn, or '?' if there is none.
+func windows1252Byte(rn rune) byte {
+	if rn < 0x80 || (rn >= 0xA0 && rn <= 0xFF) {
+		return byte(rn)
+	}
+	for i, high := range windows1252High {
+		if high == rn {
+			return byte(0x80 + i)
+		}
+	}
+	return '?'
+}
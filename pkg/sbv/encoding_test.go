@@ -0,0 +1,196 @@
+package sbv
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 in the given byte order, without a byte order mark.
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var buf []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			buf = append(buf, byte(unit>>8), byte(unit))
+		} else {
+			buf = append(buf, byte(unit), byte(unit>>8))
+		}
+	}
+	return buf
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, name := range []string{"auto", "utf-8", "UTF8", "utf-8-bom", "utf-16le", "utf-16be", "windows-1252", "cp1252"} {
+		encoding, err := ParseEncoding(name)
+		if err != nil {
+			t.Errorf("ParseEncoding(%q) unexpected error: %v", name, err)
+			continue
+		}
+		if again, _ := ParseEncoding(encoding.String()); again != encoding {
+			t.Errorf("ParseEncoding(%q).String() = %q does not round-trip", name, encoding)
+		}
+	}
+
+	if _, err := ParseEncoding("ebcdic"); err == nil {
+		t.Errorf("ParseEncoding(\"ebcdic\") expected error, got nil")
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want Encoding
+	}{
+		{"ascii", []byte("0:00:01.000,0:00:02.000\nHi\n"), EncodingUTF8},
+		{"utf-8", []byte("Café"), EncodingUTF8},
+		{"utf-8 cut mid character", []byte("Caf\xc3"), EncodingUTF8},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), "Hi"...), EncodingUTF8BOM},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16Bytes("Hi", false)...), EncodingUTF16LE},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16Bytes("Hi", true)...), EncodingUTF16BE},
+		{"utf-16le without bom", utf16Bytes("1\n00:00:01,000", false), EncodingUTF16LE},
+		{"utf-16be without bom", utf16Bytes("1\n00:00:01,000", true), EncodingUTF16BE},
+		{"windows-1252", []byte("Caf\xe9 \x93quoted\x94"), EncodingWindows1252},
+		{"empty", nil, EncodingUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.head); got != tt.want {
+				t.Errorf("DetectEncoding() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextReader(t *testing.T) {
+	const text = "Café “quoted” 😀\nNext line"
+	// Longer than the part of the input examined by DetectEncoding
+	asciiHead := strings.Repeat("plain ascii\n", 400)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		encoding Encoding
+		want     string
+		detected Encoding
+	}{
+		{"utf-8", []byte(text), EncodingAuto, text, EncodingUTF8},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), text...), EncodingAuto, text, EncodingUTF8BOM},
+		{"declared utf-8 with bom", append([]byte("\xef\xbb\xbf"), text...), EncodingUTF8, text, EncodingUTF8},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16Bytes(text, false)...), EncodingAuto, text, EncodingUTF16LE},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16Bytes(text, true)...), EncodingAuto, text, EncodingUTF16BE},
+		{"declared utf-16le", utf16Bytes(text, false), EncodingUTF16LE, text, EncodingUTF16LE},
+		{"windows-1252", []byte("Caf\xe9 \x93quoted\x94 \x80"), EncodingAuto, "Café “quoted” €", EncodingWindows1252},
+		{"declared windows-1252", []byte("Caf\xe9"), EncodingWindows1252, "Café", EncodingWindows1252},
+		{"truncated utf-16", append(utf16Bytes("Hi", false), 'x'), EncodingUTF16LE, "Hi�", EncodingUTF16LE},
+		{"ascii head then utf-8", []byte(asciiHead + text), EncodingAuto, asciiHead + text, EncodingUTF8},
+		{"ascii head then windows-1252", []byte(asciiHead + "Caf\xe9 \x80"), EncodingAuto, asciiHead + "Café €", EncodingWindows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reading one byte at a time splits characters across reads
+			reader := NewTextReader(iotest.OneByteReader(bytes.NewReader(tt.input)), tt.encoding)
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadAll() = %q, want %q", got, tt.want)
+			}
+			if reader.Encoding() != tt.detected {
+				t.Errorf("Encoding() = %s, want %s", reader.Encoding(), tt.detected)
+			}
+		})
+	}
+}
+
+func TestDetectReaderDeclaredEncoding(t *testing.T) {
+	input := NewTextReader(bytes.NewReader([]byte("0:00:01.000,0:00:02.000\nCaf\xe9\n")), EncodingWindows1252)
+
+	format, reader, err := DetectReader(input)
+	if err != nil || format.Name != "sbv" {
+		t.Fatalf("DetectReader() = %s, %v, want sbv", format.Name, err)
+	}
+	if _, ok := reader.(*TextReader); !ok {
+		t.Errorf("DetectReader() returned %T, want a TextReader so decoders keep the encoding", reader)
+	}
+	if got := input.Encoding(); got != EncodingWindows1252 {
+		t.Errorf("Encoding() = %s, want the declared windows-1252", got)
+	}
+
+	subtitles, err := format.Decoder.Decode(reader)
+	if err != nil || len(subtitles) != 1 || subtitles[0].Text != "Café" {
+		t.Errorf("Decode() = %q, %v, want one cue with text Café", subtitles, err)
+	}
+}
+
+func TestTextWriter(t *testing.T) {
+	const text = "Café “quoted” 😀\n"
+
+	tests := []struct {
+		encoding Encoding
+		want     []byte
+	}{
+		{EncodingUTF8, []byte(text)},
+		{EncodingUTF8BOM, append([]byte("\xef\xbb\xbf"), text...)},
+		{EncodingUTF16LE, append([]byte{0xFF, 0xFE}, utf16Bytes(text, false)...)},
+		{EncodingUTF16BE, append([]byte{0xFE, 0xFF}, utf16Bytes(text, true)...)},
+		{EncodingWindows1252, []byte("Caf\xe9 \x93quoted\x94 ?\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewTextWriter(&buf, tt.encoding)
+			// Write one byte at a time to split characters across writes
+			for i := 0; i < len(text); i++ {
+				if _, err := writer.Write([]byte{text[i]}); err != nil {
+					t.Fatalf("Write() unexpected error: %v", err)
+				}
+			}
+			if !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("Write() wrote % x, want % x", buf.Bytes(), tt.want)
+			}
+
+			// Whatever was written reads back, except where Windows-1252 has no character
+			if tt.encoding != EncodingWindows1252 {
+				got, err := io.ReadAll(NewTextReader(&buf, EncodingAuto))
+				if err != nil || string(got) != text {
+					t.Errorf("reading back = %q, %v, want %q", got, err, text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFromReaderEncodings(t *testing.T) {
+	const sbvText = "0:00:01.000,0:00:02.000\nCafé\n"
+
+	inputs := map[string][]byte{
+		"utf-8 bom":    append([]byte("\xef\xbb\xbf"), sbvText...),
+		"utf-16le bom": append([]byte{0xFF, 0xFE}, utf16Bytes(sbvText, false)...),
+		"windows-1252": []byte(strings.Replace(sbvText, "é", "\xe9", 1)),
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			subtitles, err := NewConverter().ParseFromReader(bytes.NewReader(input))
+			if err != nil {
+				t.Fatalf("ParseFromReader() unexpected error: %v", err)
+			}
+			if len(subtitles) != 1 || subtitles[0].Text != "Café" {
+				t.Errorf("ParseFromReader() = %q, want one cue with text Café", subtitles)
+			}
+
+			format, _, err := DetectReader(bytes.NewReader(input))
+			if err != nil || format.Name != "sbv" {
+				t.Errorf("DetectReader() = %s, %v, want sbv", format.Name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package sbv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel causes of parse failures, usable with errors.Is.
+var (
+	// ErrBadTimestamp reports a timing line that is not made of a start and an end time.
+	ErrBadTimestamp = errors.New("invalid timestamp format")
+
+	// ErrBadTime reports a start or end time that is not a valid H:MM:SS.mmm value.
+	ErrBadTime = errors.New("invalid time")
+
+	// ErrOutOfRange reports a time field outside its allowed range, such as 60 minutes.
+	ErrOutOfRange = errors.New("out of range")
+
+	// ErrMalformedLine reports a line without the structure its format
+	// requires, such as an ASS Dialogue line with too few fields.
+	ErrMalformedLine = errors.New("malformed line")
+
+	// ErrEndBeforeStart reports a cue whose end time is before its start
+	// time. Lenient parsing repairs it by swapping the times.
+	ErrEndBeforeStart = errors.New("end time is before start time")
+)
+
+// ParseError describes a cue that could not be parsed, with its position in the input.
+type ParseError struct {
+	// Line is the 1-based line number of the offending timing line.
+	Line int
+
+	// Column is the 1-based column of the first malformed time on the line.
+	Column int
+
+	// Raw is the offending line as read from the input, without surrounding whitespace.
+	Raw string
+
+	// Err is the underlying cause. It matches one of ErrBadTimestamp,
+	// ErrBadTime, ErrOutOfRange or ErrMalformedLine with errors.Is.
+	Err error
+}
+
+// Error returns the error message including the line number.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse subtitle block at line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// causedError is an error with its own message that still matches a sentinel cause.
+type causedError struct {
+	cause error
+	msg   string
+}
+
+func (e *causedError) Error() string {
+	return e.msg
+}
+
+func (e *causedError) Unwrap() error {
+	return e.cause
+}
+
+// errorf formats an error message like fmt.Errorf and attaches cause for errors.Is.
+func errorf(cause error, format string, args ...any) error {
+	return &causedError{cause: cause, msg: fmt.Sprintf(format, args...)}
+}
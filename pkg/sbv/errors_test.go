@@ -0,0 +1,148 @@
+package sbv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorFromReader(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name       string
+		content    string
+		wantLine   int
+		wantColumn int
+		wantRaw    string
+		wantCause  error
+	}{
+		{
+			name:       "bad minutes in end time",
+			content:    "0:00:01.000,0:00:02.000\nFirst\n\n0:00:03.000,0:xx:04.000\nSecond\n",
+			wantLine:   4,
+			wantColumn: 13,
+			wantRaw:    "0:00:03.000,0:xx:04.000",
+			wantCause:  ErrBadTime,
+		},
+		{
+			name:       "seconds out of range in start time",
+			content:    "\n\n0:00:61.000,0:01:02.000\nText\n",
+			wantLine:   3,
+			wantColumn: 1,
+			wantRaw:    "0:00:61.000,0:01:02.000",
+			wantCause:  ErrOutOfRange,
+		},
+		{
+			name:       "too many times on the line",
+			content:    "0:00:01.000,0:00:02.000,0:00:03.000\nText\n",
+			wantLine:   1,
+			wantColumn: 1,
+			wantRaw:    "0:00:01.000,0:00:02.000,0:00:03.000",
+			wantCause:  ErrBadTimestamp,
+		},
+		{
+			name:       "surrounding whitespace is not part of the raw line",
+			content:    "  0:00:01.000, 0:00:02.xyz  \nText\n",
+			wantLine:   1,
+			wantColumn: 14,
+			wantRaw:    "0:00:01.000, 0:00:02.xyz",
+			wantCause:  ErrBadTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := converter.ParseFromReader(strings.NewReader(tt.content))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseFromReader() error = %v, want *ParseError", err)
+			}
+			if parseErr.Line != tt.wantLine {
+				t.Errorf("ParseError.Line = %d, want %d", parseErr.Line, tt.wantLine)
+			}
+			if parseErr.Column != tt.wantColumn {
+				t.Errorf("ParseError.Column = %d, want %d", parseErr.Column, tt.wantColumn)
+			}
+			if parseErr.Raw != tt.wantRaw {
+				t.Errorf("ParseError.Raw = %q, want %q", parseErr.Raw, tt.wantRaw)
+			}
+			if !errors.Is(err, tt.wantCause) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestParseErrorFromSRTReader(t *testing.T) {
+	converter := NewConverter()
+
+	_, err := converter.ParseSRTFromReader(strings.NewReader("1\n00:00:01,000 --> 00:00:02,000\nOne\n\n2\n00:00:03,000 --> 00:00:04,1000\nTwo\n"))
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("ParseSRTFromReader() error = %v, want *ParseError", err)
+	}
+	if parseErr.Line != 6 || parseErr.Column != 18 {
+		t.Errorf("ParseError position = %d:%d, want 6:18", parseErr.Line, parseErr.Column)
+	}
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("errors.Is(%v, ErrOutOfRange) = false", err)
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := &ParseError{
+		Line:   4,
+		Column: 13,
+		Raw:    "0:00:03.000,0:xx:04.000",
+		Err:    errorf(ErrBadTime, "invalid minutes: xx"),
+	}
+
+	want := "failed to parse subtitle block at line 4: invalid minutes: xx"
+	if err.Error() != want {
+		t.Errorf("ParseError.Error() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, ErrBadTime) || errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ParseError does not unwrap to exactly its cause")
+	}
+}
+
+func TestParseTimeErrorCauses(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		input     string
+		wantCause error
+	}{
+		{input: "00:30.500", wantCause: ErrBadTime},
+		{input: "abc:00:30.500", wantCause: ErrBadTime},
+		{input: "1:60:30.500", wantCause: ErrOutOfRange},
+		{input: "1:30:45500", wantCause: ErrBadTime},
+		{input: "1:30:60.500", wantCause: ErrOutOfRange},
+		{input: "1:30:45.xyz", wantCause: ErrBadTime},
+		{input: "1:30:45.1000", wantCause: ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := converter.parseTime(tt.input)
+			if !errors.Is(err, tt.wantCause) {
+				t.Errorf("parseTime(%q) error = %v, want cause %v", tt.input, err, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestDiagnosticCarriesCause(t *testing.T) {
+	converter := NewConverter()
+
+	_, diagnostics, err := converter.ParseFromReaderLenient(strings.NewReader("0:00:03.000,0:00:04.abc\nText\n"))
+	if err != nil {
+		t.Fatalf("ParseFromReaderLenient() unexpected error: %v", err)
+	}
+	if len(diagnostics) != 1 || !errors.Is(diagnostics[0].Err, ErrBadTime) {
+		t.Errorf("diagnostics = %+v, want one with ErrBadTime cause", diagnostics)
+	}
+}
@@ -0,0 +1,123 @@
+package sbv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the permission given to new output files, before the
+// umask is applied.
+const defaultFileMode fs.FileMode = 0o666
+
+// maxSymlinks is the number of symbolic links followed before giving up.
+const maxSymlinks = 255
+
+// WriteFileAtomic hands write a buffered writer for a temporary file in the
+// directory of filename, then renames it to filename once everything has been
+// written. A failed write leaves any existing file untouched and no partial
+// output behind. An existing file keeps its permissions, and a new one gets
+// the permissions allowed by the umask. If filename is a symbolic link, the
+// file it points to is replaced and the link is kept.
+func WriteFileAtomic(filename string, write func(writer io.Writer) error) (err error) {
+	target, err := resolveSymlinks(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", filename, err)
+	}
+
+	keepMode := false
+	var mode fs.FileMode
+	if info, statErr := os.Stat(target); statErr == nil {
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("failed to create file %s: not a regular file", filename)
+		}
+		keepMode, mode = true, info.Mode().Perm()
+	}
+
+	temp, err := createTemp(target)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", filename, err)
+	}
+	defer func() {
+		if err != nil {
+			// The temporary file is only an intermediate, so errors removing it are not reported
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	buffered := bufio.NewWriter(temp)
+	if err := write(buffered); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+	if err := temp.Sync(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+	if keepMode {
+		if err := temp.Chmod(mode); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+			return fmt.Errorf("failed to set permissions of %s: %w", filename, err)
+		}
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return fmt.Errorf("failed to replace file %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// createTemp creates a new temporary file next to filename. Unlike
+// os.CreateTemp, it creates the file with defaultFileMode, so the umask
+// decides the permissions of new output files.
+func createTemp(filename string) (*os.File, error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	for range 10000 {
+		name := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, rand.Uint32()))
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, defaultFileMode)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, err
+	}
+	return nil, fmt.Errorf("no unused temporary file name in %s", dir)
+}
+
+// resolveSymlinks follows filename while it is a symbolic link and returns
+// the path of the file it points to, which need not exist yet.
+func resolveSymlinks(filename string) (string, error) {
+	for range maxSymlinks {
+		info, err := os.Lstat(filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return filename, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return filename, nil
+		}
+
+		link, err := os.Readlink(filename)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(filename), link)
+		}
+		filename = link
+	}
+	return "", fmt.Errorf("too many levels of symbolic links")
+}
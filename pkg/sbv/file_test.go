@@ -0,0 +1,120 @@
+package sbv
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "out.srt")
+	if err := os.WriteFile(filename, []byte("original"), 0o600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// A failed write leaves the existing file and no temporary file behind
+	err := WriteFileAtomic(filename, func(writer io.Writer) error {
+		if _, err := io.WriteString(writer, "partial"); err != nil {
+			return err
+		}
+		return errors.New("conversion failed")
+	})
+	if err == nil {
+		t.Fatalf("WriteFileAtomic() expected error, got nil")
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "original" {
+		t.Errorf("WriteFileAtomic() changed the file on failure: %q", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("WriteFileAtomic() left %d files in the directory, want 1", len(entries))
+	}
+
+	err = WriteFileAtomic(filename, func(writer io.Writer) error {
+		_, err := io.WriteString(writer, "replaced")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteFileAtomic() unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "replaced" {
+		t.Errorf("WriteFileAtomic() wrote %q, want %q", content, "replaced")
+	}
+	if info, err := os.Stat(filename); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("WriteFileAtomic() mode = %v, want the original 0600", info.Mode().Perm())
+	}
+
+	if err := WriteFileAtomic(dir, func(io.Writer) error { return nil }); err == nil {
+		t.Errorf("WriteFileAtomic() expected error writing over a directory, got nil")
+	}
+}
+
+func TestWriteFileAtomicNewFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+
+	// A file created directly shows the permissions the umask allows
+	dir := t.TempDir()
+	reference := filepath.Join(dir, "reference")
+	if err := os.WriteFile(reference, nil, 0o666); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	want, err := os.Stat(reference)
+	if err != nil {
+		t.Fatalf("Failed to stat test file: %v", err)
+	}
+
+	filename := filepath.Join(dir, "new.srt")
+	if err := WriteFileAtomic(filename, func(io.Writer) error { return nil }); err != nil {
+		t.Fatalf("WriteFileAtomic() unexpected error: %v", err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("WriteFileAtomic() did not create the file: %v", err)
+	}
+	if info.Mode().Perm() != want.Mode().Perm() {
+		t.Errorf("WriteFileAtomic() mode = %v, want %v", info.Mode().Perm(), want.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.srt")
+	if err := os.WriteFile(target, []byte("original"), 0o600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	link := filepath.Join(dir, "link.srt")
+	if err := os.Symlink("real.srt", link); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	dangling := filepath.Join(dir, "dangling.srt")
+	if err := os.Symlink("missing.srt", dangling); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	for _, filename := range []string{link, dangling} {
+		err := WriteFileAtomic(filename, func(writer io.Writer) error {
+			_, err := io.WriteString(writer, "replaced")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WriteFileAtomic(%s) unexpected error: %v", filename, err)
+		}
+		if info, err := os.Lstat(filename); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("WriteFileAtomic(%s) replaced the symbolic link", filename)
+		}
+	}
+
+	for _, filename := range []string{target, filepath.Join(dir, "missing.srt")} {
+		if content, _ := os.ReadFile(filename); string(content) != "replaced" {
+			t.Errorf("%s = %q, want %q", filepath.Base(filename), content, "replaced")
+		}
+	}
+	if info, err := os.Stat(target); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("WriteFileAtomic() mode = %v, want the original 0600", info.Mode().Perm())
+	}
+}
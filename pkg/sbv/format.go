@@ -0,0 +1,579 @@
+package sbv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// sniffSize is the number of leading bytes handed to Format.Sniff functions.
+const sniffSize = 512
+
+// Decoder reads subtitles in a particular format.
+type Decoder interface {
+	// Decode parses the content of reader into subtitle entries.
+	Decode(reader io.Reader) ([]Subtitle, error)
+}
+
+// Encoder writes subtitles in a particular format.
+type Encoder interface {
+	// Encode serializes subtitles and writes them to writer.
+	Encode(writer io.Writer, subtitles []Subtitle) error
+}
+
+// DecoderFunc adapts an ordinary function to the Decoder interface.
+type DecoderFunc func(reader io.Reader) ([]Subtitle, error)
+
+// Decode calls f(reader).
+func (f DecoderFunc) Decode(reader io.Reader) ([]Subtitle, error) {
+	return f(reader)
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(writer io.Writer, subtitles []Subtitle) error
+
+// Encode calls f(writer, subtitles).
+func (f EncoderFunc) Encode(writer io.Writer, subtitles []Subtitle) error {
+	return f(writer, subtitles)
+}
+
+// SBVDecoder reads subtitles in SBV format. It implements LenientDecoder.
+type SBVDecoder struct {
+	// PreserveWhitespace keeps leading and interior whitespace in cue text.
+	PreserveWhitespace bool
+}
+
+// Decode parses reader strictly.
+func (d SBVDecoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	scanner := NewScanner(reader)
+	scanner.PreserveWhitespace = d.PreserveWhitespace
+	return ReadAll(scanner)
+}
+
+// DecodeLenient parses reader, skipping or repairing malformed cues.
+func (d SBVDecoder) DecodeLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := NewScanner(reader)
+	scanner.Lenient = true
+	scanner.PreserveWhitespace = d.PreserveWhitespace
+
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
+}
+
+// SRTDecoder reads subtitles in SRT format. It implements LenientDecoder.
+type SRTDecoder struct {
+	// PreserveWhitespace keeps leading and interior whitespace in cue text.
+	PreserveWhitespace bool
+}
+
+// Decode parses reader strictly.
+func (d SRTDecoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	scanner := NewSRTScanner(reader)
+	scanner.PreserveWhitespace = d.PreserveWhitespace
+	return ReadAll(scanner)
+}
+
+// DecodeLenient parses reader, skipping or repairing malformed cues.
+func (d SRTDecoder) DecodeLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := NewSRTScanner(reader)
+	scanner.Lenient = true
+	scanner.PreserveWhitespace = d.PreserveWhitespace
+
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
+}
+
+// ASSDecoder reads the Dialogue lines of ASS and SSA scripts. It implements
+// LenientDecoder.
+type ASSDecoder struct {
+	// StripTags removes override tags instead of mapping them to markup.
+	StripTags bool
+}
+
+// Decode parses reader strictly.
+func (d ASSDecoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	scanner := NewASSScanner(reader)
+	scanner.StripTags = d.StripTags
+	return ReadAll(scanner)
+}
+
+// DecodeLenient parses reader, skipping malformed Dialogue lines.
+func (d ASSDecoder) DecodeLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := NewASSScanner(reader)
+	scanner.Lenient = true
+	scanner.StripTags = d.StripTags
+
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
+}
+
+// Format describes a subtitle format known to the registry.
+type Format struct {
+	// Name is the short, lower-case identifier of the format (e.g. "srt").
+	Name string
+
+	// Description is a human readable name shown in help output.
+	Description string
+
+	// Extensions lists the file extensions of the format, including the
+	// leading dot. The first one is used when generating file names.
+	Extensions []string
+
+	// Decoder reads the format. It is nil for write-only formats. Decoders
+	// that also implement LenientDecoder support lenient parsing.
+	Decoder Decoder
+
+	// Encoder writes the format. It is nil for read-only formats.
+	Encoder Encoder
+
+	// NewDecoder returns a Decoder configured by opts. It is optional;
+	// formats without it always read with Decoder.
+	NewDecoder func(opts DecodeOptions) Decoder
+
+	// NewEncoder returns an Encoder configured by opts. It is optional;
+	// formats without it always write with Encoder.
+	NewEncoder func(opts EncodeOptions) Encoder
+
+	// Sniff reports whether the leading bytes of a file look like this
+	// format. It is optional; formats without it are never detected.
+	Sniff func(head []byte) bool
+}
+
+// CanDecode reports whether the format can be read.
+func (f Format) CanDecode() bool {
+	return f.Decoder != nil
+}
+
+// CanEncode reports whether the format can be written.
+func (f Format) CanEncode() bool {
+	return f.Encoder != nil
+}
+
+// DecodeOptions configures the decoders returned by Format.DecoderWith. Each
+// format uses the options that apply to it and ignores the others.
+type DecodeOptions struct {
+	// PreserveWhitespace keeps leading and interior whitespace in the cue
+	// text of SBV and SRT input.
+	PreserveWhitespace bool
+
+	// StripTags removes the override tags of ASS and SSA input instead of
+	// mapping them to markup.
+	StripTags bool
+
+	// Words makes every timed segment of SRV3 and JSON3 input its own cue.
+	Words bool
+}
+
+// EncodeOptions configures the encoders returned by Format.EncoderWith. Each
+// format uses the options that apply to it and ignores the others.
+type EncodeOptions struct {
+	// Converter configures the numbering and timestamps of SBV, SRT and
+	// WebVTT output.
+	Converter []Option
+
+	// VTT holds the cue options of WebVTT output.
+	VTT VTTOptions
+
+	// ASS holds the script options of ASS and SSA output. The SSA field is
+	// set by the format.
+	ASS ASSOptions
+
+	// TTML holds the document options of TTML output.
+	TTML TTMLOptions
+}
+
+// DecoderWith returns the decoder of the format configured by opts, or
+// Decoder if the format has no options.
+func (f Format) DecoderWith(opts DecodeOptions) Decoder {
+	if f.NewDecoder != nil {
+		return f.NewDecoder(opts)
+	}
+	return f.Decoder
+}
+
+// EncoderWith returns the encoder of the format configured by opts, or
+// Encoder if the format has no options.
+func (f Format) EncoderWith(opts EncodeOptions) Encoder {
+	if f.NewEncoder != nil {
+		return f.NewEncoder(opts)
+	}
+	return f.Encoder
+}
+
+// registry holds all formats known to the package.
+var registry = struct {
+	sync.RWMutex
+	byName      map[string]Format
+	byExtension map[string]Format
+}{
+	byName:      make(map[string]Format),
+	byExtension: make(map[string]Format),
+}
+
+// Register adds a format to the registry so it can be looked up by name,
+// extension or content. Names and extensions are case-insensitive and must
+// not already be registered.
+func Register(format Format) error {
+	name := strings.ToLower(format.Name)
+	if name == "" {
+		return fmt.Errorf("format name cannot be empty")
+	}
+	if format.Decoder == nil && format.Encoder == nil {
+		return fmt.Errorf("format %s must have a decoder or an encoder", name)
+	}
+
+	extensions := make([]string, len(format.Extensions))
+	for i, ext := range format.Extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions[i] = ext
+	}
+	format.Name = name
+	format.Extensions = extensions
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, exists := registry.byName[name]; exists {
+		return fmt.Errorf("format %s is already registered", name)
+	}
+	for _, ext := range extensions {
+		if existing, exists := registry.byExtension[ext]; exists {
+			return fmt.Errorf("extension %s is already registered by format %s", ext, existing.Name)
+		}
+	}
+
+	registry.byName[name] = format
+	for _, ext := range extensions {
+		registry.byExtension[ext] = format
+	}
+
+	return nil
+}
+
+// MustRegister is like Register but panics if the format cannot be registered.
+// It is intended for use in package init functions.
+func MustRegister(format Format) {
+	if err := Register(format); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the format registered under the given name.
+func Lookup(name string) (Format, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	format, ok := registry.byName[strings.ToLower(name)]
+	return format, ok
+}
+
+// LookupExtension returns the format registered for the given file extension.
+// The extension may be given with or without the leading dot.
+func LookupExtension(ext string) (Format, bool) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	format, ok := registry.byExtension[ext]
+	return format, ok
+}
+
+// Formats returns all registered formats sorted by name.
+func Formats() []Format {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	formats := make([]Format, 0, len(registry.byName))
+	for _, format := range registry.byName {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return formats[i].Name < formats[j].Name
+	})
+
+	return formats
+}
+
+// Detect returns the first registered format, in name order, whose Sniff
+// function accepts the given leading bytes.
+func Detect(head []byte) (Format, bool) {
+	for _, format := range Formats() {
+		if format.Sniff != nil && format.Sniff(head) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// DetectReader peeks at the start of reader to detect its format. The
+// returned reader yields the complete content, including the peeked bytes,
+// decoded to UTF-8 from the encoding detected at its start. A reader that is
+// already a TextReader keeps its encoding. The returned reader is a
+// TextReader too, so decoders do not detect the encoding again.
+func DetectReader(reader io.Reader) (Format, io.Reader, error) {
+	buffered := bufio.NewReaderSize(autoTextReader(reader), sniffSize)
+	text := NewTextReader(buffered, EncodingUTF8)
+	head, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return Format{}, text, fmt.Errorf("error reading input: %w", err)
+	}
+
+	format, ok := Detect(head)
+	if !ok {
+		return Format{}, text, fmt.Errorf("unable to detect subtitle format")
+	}
+
+	return format, text, nil
+}
+
+// VTTEncoder writes subtitles in WebVTT format with the given cue options.
+type VTTEncoder struct {
+	Options VTTOptions
+}
+
+// Encode serializes subtitles as WebVTT and writes them to writer.
+func (e VTTEncoder) Encode(writer io.Writer, subtitles []Subtitle) error {
+	return NewConverter().WriteVTTToWriter(subtitles, writer, e.Options)
+}
+
+// ASSEncoder writes subtitles as an Advanced SubStation Alpha or SubStation
+// Alpha script with the given options.
+type ASSEncoder struct {
+	Options ASSOptions
+}
+
+// Encode serializes subtitles as an ASS or SSA script and writes them to
+// writer. Each cue is written as soon as it is formatted.
+func (e ASSEncoder) Encode(writer io.Writer, subtitles []Subtitle) error {
+	return writeCues(NewASSWriter(writer, e.Options), subtitles)
+}
+
+// TTMLDecoder reads the p elements of TTML and DFXP documents. It implements
+// LenientDecoder.
+type TTMLDecoder struct{}
+
+// Decode parses reader strictly.
+func (d TTMLDecoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	return ReadAll(NewTTMLScanner(reader))
+}
+
+// DecodeLenient parses reader, skipping p elements with malformed timing.
+func (d TTMLDecoder) DecodeLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := NewTTMLScanner(reader)
+	scanner.Lenient = true
+
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
+}
+
+// TTMLEncoder writes subtitles as a TTML document in the IMSC1 text profile
+// with the given options.
+type TTMLEncoder struct {
+	Options TTMLOptions
+}
+
+// Encode serializes subtitles as a TTML document and writes them to writer.
+// Each cue is written as soon as it is formatted.
+func (e TTMLEncoder) Encode(writer io.Writer, subtitles []Subtitle) error {
+	return writeCues(NewTTMLWriter(writer, e.Options), subtitles)
+}
+
+var (
+	sbvTimestampPattern = regexp.MustCompile(`^\d+:\d{1,2}:\d{1,2}\.\d{1,3},\d+:\d{1,2}:\d{1,2}\.\d{1,3}$`)
+	srtTimestampPattern = regexp.MustCompile(`^\d+:\d{1,2}:\d{1,2}[,.]\d{1,3}\s*-->`)
+	srtSequencePattern  = regexp.MustCompile(`^\d+$`)
+	srv3RootPattern     = regexp.MustCompile(`<timedtext[^>]*\sformat="3"`)
+	ttmlRootPattern     = regexp.MustCompile(`<(?:\w+:)?tt[\s>][^>]*(?:http://www\.w3\.org/ns/ttml|http://www\.w3\.org/2006/\d+/ttaf1)`)
+)
+
+// firstLine returns the first non-blank line of head, without a UTF-8 byte order mark.
+func firstLine(head []byte) string {
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	for _, line := range strings.Split(string(head), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// sniffSBV reports whether head starts with an SBV timestamp line.
+func sniffSBV(head []byte) bool {
+	return sbvTimestampPattern.MatchString(firstLine(head))
+}
+
+// sniffSRT reports whether head starts with an SRT sequence number or timing line.
+func sniffSRT(head []byte) bool {
+	line := firstLine(head)
+	return srtTimestampPattern.MatchString(line) ||
+		(srtSequencePattern.MatchString(line) && bytes.Contains(head, []byte("-->")))
+}
+
+// sniffVTT reports whether head starts with the WebVTT signature.
+func sniffVTT(head []byte) bool {
+	line := firstLine(head)
+	return line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "WEBVTT\t")
+}
+
+// sniffASS reports whether head starts with the [Script Info] section of an
+// ASS or SSA script.
+func sniffASS(head []byte) bool {
+	return strings.EqualFold(firstLine(head), "[Script Info]")
+}
+
+// sniffTTML reports whether head starts a TTML document: a tt element in
+// the TTML namespace, or in one of the namespaces of the DFXP drafts.
+func sniffTTML(head []byte) bool {
+	return ttmlRootPattern.Match(head)
+}
+
+// sniffSRV3 reports whether head starts a YouTube timedtext document in SRV3 format.
+func sniffSRV3(head []byte) bool {
+	return srv3RootPattern.Match(head)
+}
+
+// sniffJSON3 reports whether head starts a YouTube timedtext document in JSON3 format.
+func sniffJSON3(head []byte) bool {
+	return strings.HasPrefix(firstLine(head), "{") &&
+		(bytes.Contains(head, []byte(`"wireMagic"`)) || bytes.Contains(head, []byte(`"events"`)))
+}
+
+func init() {
+	converter := NewConverter()
+
+	MustRegister(Format{
+		Name:        "sbv",
+		Description: "YouTube SubViewer",
+		Extensions:  []string{".sbv"},
+		Decoder:     SBVDecoder{},
+		Encoder:     sbvEncoder(converter),
+		NewDecoder: func(opts DecodeOptions) Decoder {
+			return SBVDecoder{PreserveWhitespace: opts.PreserveWhitespace}
+		},
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			return sbvEncoder(NewConverter(opts.Converter...))
+		},
+		Sniff: sniffSBV,
+	})
+
+	MustRegister(Format{
+		Name:        "srt",
+		Description: "SubRip",
+		Extensions:  []string{".srt"},
+		Decoder:     SRTDecoder{},
+		Encoder:     srtEncoder(converter),
+		NewDecoder: func(opts DecodeOptions) Decoder {
+			return SRTDecoder{PreserveWhitespace: opts.PreserveWhitespace}
+		},
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			return srtEncoder(NewConverter(opts.Converter...))
+		},
+		Sniff: sniffSRT,
+	})
+
+	MustRegister(Format{
+		Name:        "vtt",
+		Description: "WebVTT",
+		Extensions:  []string{".vtt"},
+		Encoder:     VTTEncoder{},
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			converter := NewConverter(opts.Converter...)
+			return EncoderFunc(func(writer io.Writer, subtitles []Subtitle) error {
+				return converter.WriteVTTToWriter(subtitles, writer, opts.VTT)
+			})
+		},
+		Sniff: sniffVTT,
+	})
+
+	MustRegister(Format{
+		Name:        "ass",
+		Description: "Advanced SubStation Alpha",
+		Extensions:  []string{".ass"},
+		Decoder:     ASSDecoder{},
+		Encoder:     ASSEncoder{},
+		NewDecoder:  newASSDecoder,
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			opts.ASS.SSA = false
+			return ASSEncoder{Options: opts.ASS}
+		},
+		Sniff: sniffASS,
+	})
+
+	MustRegister(Format{
+		Name:        "ssa",
+		Description: "SubStation Alpha",
+		Extensions:  []string{".ssa"},
+		Decoder:     ASSDecoder{},
+		Encoder:     ASSEncoder{Options: ASSOptions{SSA: true}},
+		NewDecoder:  newASSDecoder,
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			opts.ASS.SSA = true
+			return ASSEncoder{Options: opts.ASS}
+		},
+	})
+
+	MustRegister(Format{
+		Name:        "ttml",
+		Description: "Timed Text Markup Language (IMSC1, DFXP)",
+		Extensions:  []string{".ttml", ".dfxp"},
+		Decoder:     TTMLDecoder{},
+		Encoder:     TTMLEncoder{},
+		NewEncoder: func(opts EncodeOptions) Encoder {
+			return TTMLEncoder{Options: opts.TTML}
+		},
+		Sniff: sniffTTML,
+	})
+
+	MustRegister(Format{
+		Name:        "srv3",
+		Description: "YouTube timedtext XML",
+		Extensions:  []string{".srv3"},
+		Decoder:     SRV3Decoder{},
+		NewDecoder: func(opts DecodeOptions) Decoder {
+			return SRV3Decoder{Words: opts.Words}
+		},
+		Sniff: sniffSRV3,
+	})
+
+	MustRegister(Format{
+		Name:        "json3",
+		Description: "YouTube timedtext JSON",
+		Extensions:  []string{".json3"},
+		Decoder:     JSON3Decoder{},
+		NewDecoder: func(opts DecodeOptions) Decoder {
+			return JSON3Decoder{Words: opts.Words}
+		},
+		Sniff: sniffJSON3,
+	})
+}
+
+// sbvEncoder returns an Encoder writing SBV with converter's settings.
+func sbvEncoder(converter *DefaultConverter) Encoder {
+	return EncoderFunc(func(writer io.Writer, subtitles []Subtitle) error {
+		return converter.WriteSBVToWriter(subtitles, writer)
+	})
+}
+
+// srtEncoder returns an Encoder writing SRT with converter's settings.
+func srtEncoder(converter *DefaultConverter) Encoder {
+	return EncoderFunc(func(writer io.Writer, subtitles []Subtitle) error {
+		return converter.WriteToWriter(subtitles, writer)
+	})
+}
+
+// newASSDecoder returns an ASSDecoder configured by opts.
+func newASSDecoder(opts DecodeOptions) Decoder {
+	return ASSDecoder{StripTags: opts.StripTags}
+}
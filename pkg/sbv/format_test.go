@@ -0,0 +1,282 @@
+package sbv
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		ext       string
+		canDecode bool
+		canEncode bool
+	}{
+		{name: "sbv", ext: ".sbv", canDecode: true, canEncode: true},
+		{name: "srt", ext: "SRT", canDecode: true, canEncode: true},
+		{name: "vtt", ext: ".vtt", canDecode: false, canEncode: true},
+		{name: "ass", ext: ".ass", canDecode: true, canEncode: true},
+		{name: "ssa", ext: ".ssa", canDecode: true, canEncode: true},
+		{name: "ttml", ext: ".ttml", canDecode: true, canEncode: true},
+		{name: "ttml", ext: ".DFXP", canDecode: true, canEncode: true},
+		{name: "srv3", ext: ".srv3", canDecode: true, canEncode: false},
+		{name: "json3", ext: ".json3", canDecode: true, canEncode: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byName, ok := Lookup(strings.ToUpper(tt.name))
+			if !ok {
+				t.Fatalf("Lookup(%q) found nothing", tt.name)
+			}
+			byExt, ok := LookupExtension(tt.ext)
+			if !ok {
+				t.Fatalf("LookupExtension(%q) found nothing", tt.ext)
+			}
+			if byName.Name != tt.name || byExt.Name != tt.name {
+				t.Errorf("lookups returned %q and %q, want %q", byName.Name, byExt.Name, tt.name)
+			}
+			if byName.CanDecode() != tt.canDecode {
+				t.Errorf("CanDecode() = %v, want %v", byName.CanDecode(), tt.canDecode)
+			}
+			if byName.CanEncode() != tt.canEncode {
+				t.Errorf("CanEncode() = %v, want %v", byName.CanEncode(), tt.canEncode)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	upper := Format{
+		Name:        "Upper-Test",
+		Description: "Upper case test format",
+		Extensions:  []string{"UPT"},
+		Decoder: DecoderFunc(func(reader io.Reader) ([]Subtitle, error) {
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+			return []Subtitle{{EndTime: time.Second, Text: strings.ToLower(string(content))}}, nil
+		}),
+		Encoder: EncoderFunc(func(writer io.Writer, subtitles []Subtitle) error {
+			for _, subtitle := range subtitles {
+				if _, err := io.WriteString(writer, strings.ToUpper(subtitle.Text)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		Sniff: func(head []byte) bool {
+			return bytes.HasPrefix(head, []byte("UPPER:"))
+		},
+	}
+
+	if err := Register(upper); err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+
+	format, ok := LookupExtension(".upt")
+	if !ok || format.Name != "upper-test" {
+		t.Fatalf("LookupExtension(.upt) = %+v, %v", format, ok)
+	}
+
+	format, reader, err := DetectReader(strings.NewReader("UPPER:HELLO"))
+	if err != nil {
+		t.Fatalf("DetectReader() unexpected error: %v", err)
+	}
+	if format.Name != "upper-test" {
+		t.Fatalf("DetectReader() = %q, want upper-test", format.Name)
+	}
+
+	subtitles, err := format.Decoder.Decode(reader)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if len(subtitles) != 1 || subtitles[0].Text != "upper:hello" {
+		t.Fatalf("Decode() = %+v, want the complete peeked content", subtitles)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Encoder.Encode(&buf, subtitles); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	if buf.String() != "UPPER:HELLO" {
+		t.Errorf("Encode() wrote %q, want %q", buf.String(), "UPPER:HELLO")
+	}
+
+	errorCases := []struct {
+		name   string
+		format Format
+	}{
+		{name: "empty name", format: Format{Encoder: upper.Encoder}},
+		{name: "no codec", format: Format{Name: "nothing"}},
+		{name: "duplicate name", format: Format{Name: "SRT", Encoder: upper.Encoder}},
+		{name: "duplicate extension", format: Format{Name: "other", Extensions: []string{".sbv"}, Encoder: upper.Encoder}},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Register(tt.format); err == nil {
+				t.Errorf("Register() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "sbv",
+			content: "0:00:01.000,0:00:04.000\nHello\n",
+			want:    "sbv",
+			wantOK:  true,
+		},
+		{
+			name:    "sbv with BOM and leading blank line",
+			content: "\xef\xbb\xbf\n0:00:01.000,0:00:04.000\nHello\n",
+			want:    "sbv",
+			wantOK:  true,
+		},
+		{
+			name:    "srt with sequence number",
+			content: "1\n00:00:01,000 --> 00:00:04,000\nHello\n",
+			want:    "srt",
+			wantOK:  true,
+		},
+		{
+			name:    "srt without sequence number",
+			content: "00:00:01,000 --> 00:00:04,000\nHello\n",
+			want:    "srt",
+			wantOK:  true,
+		},
+		{
+			name:    "vtt",
+			content: "WEBVTT - captions\n\n00:00:01.000 --> 00:00:04.000\nHello\n",
+			want:    "vtt",
+			wantOK:  true,
+		},
+		{
+			name:    "ass",
+			content: "[Script Info]\nScriptType: v4.00+\n",
+			want:    "ass",
+			wantOK:  true,
+		},
+		{
+			name:    "ttml",
+			content: "<?xml version=\"1.0\"?>\n<tt xmlns=\"http://www.w3.org/ns/ttml\" xml:lang=\"en\">\n",
+			want:    "ttml",
+			wantOK:  true,
+		},
+		{
+			name:    "dfxp draft namespace",
+			content: "<tt:tt xmlns:tt=\"http://www.w3.org/2006/10/ttaf1\">",
+			want:    "ttml",
+			wantOK:  true,
+		},
+		{
+			name:    "srv3",
+			content: "<?xml version=\"1.0\" encoding=\"utf-8\" ?><timedtext format=\"3\">\n<body>\n",
+			want:    "srv3",
+			wantOK:  true,
+		},
+		{
+			name:    "json3",
+			content: "{\n  \"wireMagic\": \"pb3\",\n  \"events\": []\n}\n",
+			want:    "json3",
+			wantOK:  true,
+		},
+		{
+			name:    "plain text",
+			content: "Hello\n",
+			wantOK:  false,
+		},
+		{
+			name:    "empty",
+			content: "",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := Detect([]byte(tt.content))
+			if ok != tt.wantOK {
+				t.Fatalf("Detect() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && format.Name != tt.want {
+				t.Errorf("Detect() = %q, want %q", format.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestVTTEncoder(t *testing.T) {
+	encoder := VTTEncoder{Options: VTTOptions{CueIdentifiers: true}}
+
+	var buf bytes.Buffer
+	err := encoder.Encode(&buf, []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}})
+	if err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+
+	expected := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nHi\n\n"
+	if buf.String() != expected {
+		t.Errorf("Encode() wrote %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}}
+	encode := func(name string, opts EncodeOptions) string {
+		t.Helper()
+		format, _ := Lookup(name)
+		var buf bytes.Buffer
+		if err := format.EncoderWith(opts).Encode(&buf, subtitles); err != nil {
+			t.Fatalf("Encode() unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+
+	opts := EncodeOptions{
+		Converter: []Option{WithStartIndex(5)},
+		VTT:       VTTOptions{CueIdentifiers: true},
+		ASS:       ASSOptions{Title: "Episode 1"},
+		TTML:      TTMLOptions{Language: "fr"},
+	}
+	if got := encode("srt", opts); !strings.HasPrefix(got, "5\n") {
+		t.Errorf("srt output = %q, want cues numbered from 5", got)
+	}
+	if got := encode("vtt", opts); !strings.Contains(got, "\n5\n00:00:01.000") {
+		t.Errorf("vtt output = %q, want cue identifier 5", got)
+	}
+	if got := encode("ass", opts); !strings.Contains(got, "Title: Episode 1\n") || !strings.Contains(got, "[V4+ Styles]") {
+		t.Errorf("ass output = %q, want an ASS script titled Episode 1", got)
+	}
+	if got := encode("ssa", opts); !strings.Contains(got, "Title: Episode 1\n") || !strings.Contains(got, "[V4 Styles]") {
+		t.Errorf("ssa output = %q, want an SSA script titled Episode 1", got)
+	}
+	if got := encode("ttml", opts); !strings.Contains(got, `xml:lang="fr"`) {
+		t.Errorf("ttml output = %q, want language fr", got)
+	}
+
+	format, _ := Lookup("ass")
+	decoder := format.DecoderWith(DecodeOptions{StripTags: true})
+	got, err := decoder.Decode(strings.NewReader("[Events]\nFormat: Start, End, Text\nDialogue: 0:00:01.00,0:00:02.00,{\\i1}Hi\n"))
+	if err != nil || len(got) != 1 || got[0].Text != "Hi" {
+		t.Errorf("Decode() = %+v, %v, want one cue without tags", got, err)
+	}
+
+	// Formats without options use their Decoder and Encoder
+	custom := Format{Decoder: SBVDecoder{}, Encoder: VTTEncoder{}}
+	if custom.DecoderWith(DecodeOptions{Words: true}) != custom.Decoder || custom.EncoderWith(opts) != custom.Encoder {
+		t.Error("DecoderWith() and EncoderWith() do not fall back to Decoder and Encoder")
+	}
+}
@@ -0,0 +1,197 @@
+package sbv
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MergeOptions selects which adjacent cues MergeCues joins. A zero field
+// disables its rule.
+type MergeOptions struct {
+	// MinDuration joins cues shorter than this with the following cue, or
+	// with the previous one for the last cue.
+	MinDuration time.Duration
+
+	// MaxGap joins cues separated by at most this pause.
+	MaxGap time.Duration
+
+	// MaxDuration stops a merge that would make a cue longer than this.
+	MaxDuration time.Duration
+}
+
+// MergeCues returns a copy of subtitles in which fragments are joined into
+// longer cues. Joined cues span both originals, and their text is joined
+// with a space, since fragments are usually parts of one sentence. Cues are
+// expected in start order.
+func MergeCues(subtitles []Subtitle, opts MergeOptions) []Subtitle {
+	var merged []Subtitle
+	for _, subtitle := range subtitles {
+		n := len(merged)
+		if n > 0 && shouldMerge(merged[n-1], subtitle, opts) {
+			merged[n-1] = joinCues(merged[n-1], subtitle)
+			continue
+		}
+		merged = append(merged, subtitle)
+	}
+
+	// A short last cue has no following cue to join, so join the previous one
+	if n := len(merged); n > 1 && opts.MinDuration > 0 {
+		last := merged[n-1]
+		if last.EndTime-last.StartTime < opts.MinDuration && withinMaxDuration(merged[n-2], last, opts) {
+			merged[n-2] = joinCues(merged[n-2], last)
+			merged = merged[:n-1]
+		}
+	}
+
+	return merged
+}
+
+// shouldMerge reports whether b should be joined to the cue a before it.
+func shouldMerge(a, b Subtitle, opts MergeOptions) bool {
+	if !withinMaxDuration(a, b, opts) {
+		return false
+	}
+	if opts.MinDuration > 0 && a.EndTime-a.StartTime < opts.MinDuration {
+		return true
+	}
+	return opts.MaxGap > 0 && b.StartTime-a.EndTime <= opts.MaxGap
+}
+
+// withinMaxDuration reports whether joining a and b respects MaxDuration.
+func withinMaxDuration(a, b Subtitle, opts MergeOptions) bool {
+	return opts.MaxDuration <= 0 || max(a.EndTime, b.EndTime)-min(a.StartTime, b.StartTime) <= opts.MaxDuration
+}
+
+// joinCues returns a cue spanning a and b with their text on one line.
+func joinCues(a, b Subtitle) Subtitle {
+	text := strings.TrimSpace(a.Text + " " + b.Text)
+	return Subtitle{
+		StartTime: min(a.StartTime, b.StartTime),
+		EndTime:   max(a.EndTime, b.EndTime),
+		Text:      text,
+	}
+}
+
+// Split boundary strengths, from best to worst.
+const (
+	boundaryNone = iota
+	boundaryWord
+	boundaryClause
+	boundarySentence
+)
+
+// SplitLongCues returns a copy of subtitles in which every cue longer than
+// maxDuration is split into shorter cues, preferably at the end of a
+// sentence, then after a comma or similar, and otherwise between words.
+// Each part gets a share of the duration proportional to its text width.
+// Cues that cannot be split further are kept as they are.
+func SplitLongCues(subtitles []Subtitle, maxDuration time.Duration) []Subtitle {
+	if maxDuration <= 0 {
+		return append([]Subtitle(nil), subtitles...)
+	}
+
+	var split []Subtitle
+	for _, subtitle := range subtitles {
+		split = append(split, splitLongCue(subtitle, maxDuration)...)
+	}
+	return split
+}
+
+// splitLongCue splits a cue in two at its best boundary and recurses into
+// both halves until they fit in maxDuration. A cue is kept whole if either
+// half would have no text, or if its text has no width to share the time by.
+func splitLongCue(subtitle Subtitle, maxDuration time.Duration) []Subtitle {
+	duration := subtitle.EndTime - subtitle.StartTime
+	if duration <= maxDuration {
+		return []Subtitle{subtitle}
+	}
+
+	// Surrounding whitespace would only make empty halves
+	text := []rune(strings.TrimSpace(subtitle.Text))
+	at := bestBoundary(text)
+	if at <= 0 {
+		return []Subtitle{subtitle}
+	}
+
+	left := strings.TrimSpace(string(text[:at]))
+	right := strings.TrimSpace(string(text[at:]))
+	total := DisplayWidth(left) + DisplayWidth(right)
+	if left == "" || right == "" || total == 0 {
+		return []Subtitle{subtitle}
+	}
+	splitTime := subtitle.StartTime + time.Duration(float64(duration)*float64(DisplayWidth(left))/float64(total))
+
+	first := Subtitle{StartTime: subtitle.StartTime, EndTime: splitTime, Text: left}
+	second := Subtitle{StartTime: splitTime, EndTime: subtitle.EndTime, Text: right}
+	return append(splitLongCue(first, maxDuration), splitLongCue(second, maxDuration)...)
+}
+
+// bestBoundary returns the rune index at which text is best split in two,
+// or 0 if it cannot be split. The strongest boundary in the middle half of
+// the text wins; without one, the strongest boundary anywhere, closest to
+// the middle.
+func bestBoundary(text []rune) int {
+	best, bestStrength, bestCentral := 0, boundaryNone, false
+	middle := len(text) / 2
+
+	for i := 1; i < len(text); i++ {
+		strength := boundaryAt(text, i)
+		if strength == boundaryNone {
+			continue
+		}
+
+		central := i >= len(text)/4 && i <= len(text)*3/4
+		better := false
+		switch {
+		case central != bestCentral:
+			better = central
+		case strength != bestStrength:
+			better = strength > bestStrength
+		default:
+			better = abs(i-middle) < abs(best-middle)
+		}
+		if better {
+			best, bestStrength, bestCentral = i, strength, central
+		}
+	}
+
+	return best
+}
+
+// boundaryAt returns how good a place it is to split text before index i.
+func boundaryAt(text []rune, i int) int {
+	previous, next := text[i-1], text[i]
+
+	// Full-width punctuation ends a sentence or clause without a space
+	switch previous {
+	case '。', '！', '？':
+		return boundarySentence
+	case '、', '，', '；':
+		return boundaryClause
+	}
+
+	// Text without spaces may be split between any two wide characters
+	if runeWidth(previous) == 2 && runeWidth(next) == 2 {
+		return boundaryWord
+	}
+
+	if !unicode.IsSpace(next) || unicode.IsSpace(previous) {
+		return boundaryNone
+	}
+	switch previous {
+	case '.', '!', '?', '…':
+		return boundarySentence
+	case ',', ';', ':', '-', '–', '—':
+		return boundaryClause
+	}
+	return boundaryWord
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
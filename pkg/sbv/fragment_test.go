@@ -0,0 +1,141 @@
+package sbv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeCues(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtitles []Subtitle
+		opts      MergeOptions
+		want      []Subtitle
+	}{
+		{
+			name:      "disabled",
+			subtitles: []Subtitle{cue(0, 0.5, "so"), cue(0.5, 1, "we")},
+			want:      []Subtitle{cue(0, 0.5, "so"), cue(0.5, 1, "we")},
+		},
+		{
+			name:      "short cues",
+			subtitles: []Subtitle{cue(0, 0.5, "so"), cue(0.6, 0.9, "we"), cue(1, 3, "went home"), cue(10, 13, "Later")},
+			opts:      MergeOptions{MinDuration: time.Second},
+			want:      []Subtitle{cue(0, 3, "so we went home"), cue(10, 13, "Later")},
+		},
+		{
+			name:      "short last cue joins the previous one",
+			subtitles: []Subtitle{cue(0, 2, "the end"), cue(2.5, 2.8, "ok")},
+			opts:      MergeOptions{MinDuration: time.Second},
+			want:      []Subtitle{cue(0, 2.8, "the end ok")},
+		},
+		{
+			name:      "small gaps",
+			subtitles: []Subtitle{cue(0, 2, "one"), cue(2.1, 4, "two"), cue(5, 7, "three")},
+			opts:      MergeOptions{MaxGap: 200 * time.Millisecond},
+			want:      []Subtitle{cue(0, 4, "one two"), cue(5, 7, "three")},
+		},
+		{
+			name:      "maximum duration",
+			subtitles: []Subtitle{cue(0, 2, "one"), cue(2, 4, "two"), cue(4, 6, "three")},
+			opts:      MergeOptions{MaxGap: time.Second, MaxDuration: 5 * time.Second},
+			want:      []Subtitle{cue(0, 4, "one two"), cue(4, 6, "three")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeCues(tt.subtitles, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeCues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLongCues(t *testing.T) {
+	tests := []struct {
+		name        string
+		subtitle    Subtitle
+		maxDuration time.Duration
+		want        []Subtitle
+	}{
+		{
+			name:        "short enough",
+			subtitle:    cue(0, 4, "Hello there. How are you?"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 4, "Hello there. How are you?")},
+		},
+		{
+			name:        "sentence boundary",
+			subtitle:    cue(0, 10, "Hello there. How are you?"),
+			maxDuration: 6 * time.Second,
+			// 12 and 12 columns of text
+			want: []Subtitle{cue(0, 5, "Hello there."), cue(5, 10, "How are you?")},
+		},
+		{
+			name:        "clause boundary before word boundary",
+			subtitle:    cue(0, 30, "alpha beta, gamma delta epsilon"),
+			maxDuration: 20 * time.Second,
+			want:        []Subtitle{cue(0, 11, "alpha beta,"), cue(11, 30, "gamma delta epsilon")},
+		},
+		{
+			name:        "word boundary",
+			subtitle:    cue(0, 8, "abc def ghi jkl"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 4, "abc def"), cue(4, 8, "ghi jkl")},
+		},
+		{
+			name:        "recursive",
+			subtitle:    cue(0, 13, "One. Two. Six."),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 4.5, "One."), cue(4.5, 9, "Two."), cue(9, 13, "Six.")},
+		},
+		{
+			name:        "cjk punctuation",
+			subtitle:    cue(0, 11, "こんにちは。元気ですか"),
+			maxDuration: 6 * time.Second,
+			want:        []Subtitle{cue(0, 6, "こんにちは。"), cue(6, 11, "元気ですか")},
+		},
+		{
+			name:        "cjk without punctuation",
+			subtitle:    cue(0, 8, "あいうえ"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 4, "あい"), cue(4, 8, "うえ")},
+		},
+		{
+			name:        "cannot split",
+			subtitle:    cue(0, 10, "Supercalifragilistic"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 10, "Supercalifragilistic")},
+		},
+		{
+			name:        "trailing whitespace makes no empty cue",
+			subtitle:    cue(0, 10, "Supercalifragilistic. \n"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 10, "Supercalifragilistic. \n")},
+		},
+		{
+			name:        "surrounding whitespace ignored",
+			subtitle:    cue(0, 10, "  Hello there. How are you?\n"),
+			maxDuration: 6 * time.Second,
+			want:        []Subtitle{cue(0, 5, "Hello there."), cue(5, 10, "How are you?")},
+		},
+		{
+			name:        "text without width",
+			subtitle:    cue(0, 10, "\u200b \u200b"),
+			maxDuration: 5 * time.Second,
+			want:        []Subtitle{cue(0, 10, "\u200b \u200b")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitLongCues([]Subtitle{tt.subtitle}, tt.maxDuration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitLongCues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
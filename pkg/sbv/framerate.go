@@ -0,0 +1,120 @@
+package sbv
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// FrameRate is a video frame rate expressed as the exact fraction Num/Den
+// frames per second, so NTSC rates such as 24000/1001 are not rounded.
+type FrameRate struct {
+	Num int64
+	Den int64
+}
+
+// Common frame rates.
+var (
+	FPS23976 = FrameRate{24000, 1001}
+	FPS24    = FrameRate{24, 1}
+	FPS25    = FrameRate{25, 1}
+	FPS2997  = FrameRate{30000, 1001}
+	FPS30    = FrameRate{30, 1}
+	FPS50    = FrameRate{50, 1}
+	FPS5994  = FrameRate{60000, 1001}
+	FPS60    = FrameRate{60, 1}
+)
+
+// ntscRates maps the usual decimal spellings of NTSC rates to their exact value.
+var ntscRates = map[string]FrameRate{
+	"23.976": FPS23976,
+	"23.98":  FPS23976,
+	"29.97":  FPS2997,
+	"47.952": {48000, 1001},
+	"47.95":  {48000, 1001},
+	"59.94":  FPS5994,
+	"119.88": {120000, 1001},
+}
+
+// ParseFrameRate parses a frame rate given as an integer ("25"), a decimal
+// ("12.5"), a fraction ("30000/1001"), or one of the usual decimal spellings
+// of NTSC rates ("23.976", "29.97", "59.94"), which map to their exact
+// fractions.
+func ParseFrameRate(s string) (FrameRate, error) {
+	s = strings.TrimSpace(s)
+	if rate, ok := ntscRates[s]; ok {
+		return rate, nil
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 || !r.Num().IsInt64() || !r.Denom().IsInt64() {
+		return FrameRate{}, fmt.Errorf("invalid frame rate: %s", s)
+	}
+
+	return FrameRate{Num: r.Num().Int64(), Den: r.Denom().Int64()}, nil
+}
+
+// String formats the frame rate as a whole number, or as a fraction if it
+// is not one.
+func (r FrameRate) String() string {
+	if r.Den == 1 {
+		return fmt.Sprintf("%d", r.Num)
+	}
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
+// FPS returns the frame rate as frames per second.
+func (r FrameRate) FPS() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// valid reports whether the frame rate is positive.
+func (r FrameRate) valid() bool {
+	return r.Num > 0 && r.Den > 0
+}
+
+// ConvertFrameRate retimes subtitles for video conformed from one frame rate
+// to another, so that each cue stays on the same frame. Converting 23.976 fps
+// to 25 fps (PAL speed-up) makes every cue about 4% earlier and shorter.
+func ConvertFrameRate(subtitles []Subtitle, from, to FrameRate) ([]Subtitle, error) {
+	if !from.valid() || !to.valid() {
+		return nil, fmt.Errorf("frame rates must be positive: %s, %s", from, to)
+	}
+
+	factor := float64(from.Num) * float64(to.Den) / (float64(from.Den) * float64(to.Num))
+	return Retime(subtitles, LinearMapping(factor, 0)), nil
+}
+
+// SnapToFrames returns a copy of subtitles with every start and end time
+// rounded to the nearest frame boundary. Cues are kept at least one frame long.
+func SnapToFrames(subtitles []Subtitle, rate FrameRate) ([]Subtitle, error) {
+	if !rate.valid() {
+		return nil, fmt.Errorf("frame rate must be positive: %s", rate)
+	}
+
+	snapped := make([]Subtitle, len(subtitles))
+	for i, subtitle := range subtitles {
+		start := nearestFrame(subtitle.StartTime, rate)
+		end := nearestFrame(subtitle.EndTime, rate)
+		if end <= start {
+			end = start + 1
+		}
+		subtitle.StartTime = frameTime(start, rate)
+		subtitle.EndTime = frameTime(end, rate)
+		snapped[i] = subtitle
+	}
+
+	return snapped, nil
+}
+
+// nearestFrame returns the number of the frame boundary closest to t.
+func nearestFrame(t time.Duration, rate FrameRate) int64 {
+	return int64(math.Round(t.Seconds() * float64(rate.Num) / float64(rate.Den)))
+}
+
+// frameTime returns the time at which the given frame starts.
+func frameTime(frame int64, rate FrameRate) time.Duration {
+	return time.Duration(math.Round(float64(frame) * float64(rate.Den) * float64(time.Second) / float64(rate.Num)))
+}
@@ -0,0 +1,103 @@
+package sbv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFrameRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    FrameRate
+		wantErr bool
+	}{
+		{input: "25", want: FPS25},
+		{input: "23.976", want: FPS23976},
+		{input: "29.97", want: FPS2997},
+		{input: "59.94", want: FPS5994},
+		{input: "30000/1001", want: FPS2997},
+		{input: "12.5", want: FrameRate{25, 2}},
+		{input: "0", wantErr: true},
+		{input: "-24", wantErr: true},
+		{input: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFrameRate(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFrameRate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFrameRate(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFrameRateString(t *testing.T) {
+	if got := FPS25.String(); got != "25" {
+		t.Errorf("String() = %q, want %q", got, "25")
+	}
+	if got := FPS23976.String(); got != "24000/1001" {
+		t.Errorf("String() = %q, want %q", got, "24000/1001")
+	}
+}
+
+func TestConvertFrameRate(t *testing.T) {
+	subtitles := []Subtitle{
+		// Frame 24000 at 23.976 fps, frame 24000 at 25 fps is at 960s
+		{StartTime: 1001 * time.Second, EndTime: 1002 * time.Second, Text: "Hello"},
+	}
+
+	got, err := ConvertFrameRate(subtitles, FPS23976, FPS25)
+	if err != nil {
+		t.Fatalf("ConvertFrameRate() unexpected error: %v", err)
+	}
+	want := [][2]time.Duration{{960 * time.Second, 960959040959}}
+	if !reflect.DeepEqual(cueTimes(got), want) {
+		t.Errorf("ConvertFrameRate() = %v, want %v", cueTimes(got), want)
+	}
+
+	back, err := ConvertFrameRate(got, FPS25, FPS23976)
+	if err != nil {
+		t.Fatalf("ConvertFrameRate() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cueTimes(back), cueTimes(subtitles)) {
+		t.Errorf("ConvertFrameRate() round trip = %v, want %v", cueTimes(back), cueTimes(subtitles))
+	}
+
+	if _, err := ConvertFrameRate(subtitles, FrameRate{}, FPS25); err == nil {
+		t.Errorf("ConvertFrameRate() expected error for zero frame rate, got nil")
+	}
+}
+
+func TestSnapToFrames(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1010 * time.Millisecond, EndTime: 2030 * time.Millisecond},
+		// Both ends round to the same frame
+		{StartTime: 3001 * time.Millisecond, EndTime: 3005 * time.Millisecond},
+	}
+
+	got, err := SnapToFrames(subtitles, FPS25)
+	if err != nil {
+		t.Fatalf("SnapToFrames() unexpected error: %v", err)
+	}
+	want := [][2]time.Duration{
+		{1000 * time.Millisecond, 2040 * time.Millisecond},
+		{3000 * time.Millisecond, 3040 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(cueTimes(got), want) {
+		t.Errorf("SnapToFrames() = %v, want %v", cueTimes(got), want)
+	}
+
+	ntsc, err := SnapToFrames([]Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second}}, FPS2997)
+	if err != nil {
+		t.Fatalf("SnapToFrames() unexpected error: %v", err)
+	}
+	// Frame 30 and 60 at 29.97 fps
+	want = [][2]time.Duration{{1001 * time.Millisecond, 2002 * time.Millisecond}}
+	if !reflect.DeepEqual(cueTimes(ntsc), want) {
+		t.Errorf("SnapToFrames() = %v, want %v", cueTimes(ntsc), want)
+	}
+}
@@ -0,0 +1,108 @@
+package sbv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LineEnding is the sequence written at the end of every output line.
+type LineEnding string
+
+const (
+	// LF ends lines with a line feed, as on Unix and in the package's own output.
+	LF LineEnding = "\n"
+	// CRLF ends lines with a carriage return and a line feed, as expected by
+	// Windows players and some broadcast ingest tools.
+	CRLF LineEnding = "\r\n"
+)
+
+// ParseLineEnding returns the line ending with the given name: lf or crlf.
+func ParseLineEnding(name string) (LineEnding, error) {
+	switch strings.ToLower(name) {
+	case "lf":
+		return LF, nil
+	case "crlf":
+		return CRLF, nil
+	}
+	return LF, fmt.Errorf("unknown line ending: %s (must be one of lf, crlf)", name)
+}
+
+// String returns the name of the line ending.
+func (e LineEnding) String() string {
+	switch e {
+	case LF:
+		return "lf"
+	case CRLF:
+		return "crlf"
+	}
+	return fmt.Sprintf("%q", string(e))
+}
+
+// LineEndingWriter rewrites the line feeds of text written to it to a given
+// line ending. Line feeds already preceded by a carriage return are kept as
+// they are, so CRLF text is not doubled up.
+type LineEndingWriter struct {
+	dst    io.Writer
+	ending LineEnding
+	lastCR bool // the last byte written was a carriage return
+}
+
+// NewLineEndingWriter returns a writer that writes to writer with every line
+// ending in ending.
+func NewLineEndingWriter(writer io.Writer, ending LineEnding) *LineEndingWriter {
+	return &LineEndingWriter{dst: writer, ending: ending}
+}
+
+// Write rewrites the line endings in p and writes it to the underlying writer.
+func (w *LineEndingWriter) Write(p []byte) (int, error) {
+	if w.ending == LF || len(p) == 0 {
+		if len(p) > 0 {
+			w.lastCR = p[len(p)-1] == '\r'
+		}
+		return w.dst.Write(p)
+	}
+
+	out := make([]byte, 0, len(p)+bytes.Count(p, []byte{'\n'})*len(w.ending))
+	for _, b := range p {
+		if b == '\n' && !w.lastCR {
+			out = append(out, w.ending...)
+		} else {
+			out = append(out, b)
+		}
+		w.lastCR = b == '\r'
+	}
+
+	if _, err := w.dst.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// scanLines is a bufio.SplitFunc that splits input at "\n", "\r\n" or a lone
+// "\r", as written by old Mac tools, and drops the line ending.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A carriage return may be the first half of a CRLF split across reads
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
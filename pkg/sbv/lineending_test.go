@@ -0,0 +1,105 @@
+package sbv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestParseLineEnding(t *testing.T) {
+	for _, name := range []string{"lf", "CRLF"} {
+		ending, err := ParseLineEnding(name)
+		if err != nil {
+			t.Errorf("ParseLineEnding(%q) unexpected error: %v", name, err)
+			continue
+		}
+		if ending.String() != strings.ToLower(name) {
+			t.Errorf("ParseLineEnding(%q).String() = %q", name, ending)
+		}
+	}
+
+	if _, err := ParseLineEnding("cr"); err == nil {
+		t.Errorf("ParseLineEnding(\"cr\") expected error, got nil")
+	}
+}
+
+func TestLineEndingWriter(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: time.Second, EndTime: 2 * time.Second, Text: "First\nline"},
+		{StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "Already\r\nCRLF"},
+	}
+
+	var buf bytes.Buffer
+	if err := NewConverter().WriteToWriter(subtitles, NewLineEndingWriter(&buf, CRLF)); err != nil {
+		t.Fatalf("WriteToWriter() unexpected error: %v", err)
+	}
+
+	want := "1\r\n00:00:01,000 --> 00:00:02,000\r\nFirst\r\nline\r\n\r\n" +
+		"2\r\n00:00:03,000 --> 00:00:04,000\r\nAlready\r\nCRLF\r\n\r\n"
+	if buf.String() != want {
+		t.Errorf("CRLF output = %q, want %q", buf.String(), want)
+	}
+
+	// Written a byte at a time, a CRLF pair is still recognised
+	buf.Reset()
+	writer := NewLineEndingWriter(&buf, CRLF)
+	for _, b := range []byte("a\r\nb\n") {
+		if _, err := writer.Write([]byte{b}); err != nil {
+			t.Fatalf("Write() unexpected error: %v", err)
+		}
+	}
+	if buf.String() != "a\r\nb\r\n" {
+		t.Errorf("byte-wise CRLF output = %q, want %q", buf.String(), "a\r\nb\r\n")
+	}
+}
+
+func TestScannerLineEndings(t *testing.T) {
+	inputs := map[string]string{
+		"lf":    "0:00:01.000,0:00:02.000\nOne\nTwo\n\n0:00:03.000,0:00:04.000\nThree\n",
+		"crlf":  "0:00:01.000,0:00:02.000\r\nOne\r\nTwo\r\n\r\n0:00:03.000,0:00:04.000\r\nThree\r\n",
+		"cr":    "0:00:01.000,0:00:02.000\rOne\rTwo\r\r0:00:03.000,0:00:04.000\rThree\r",
+		"mixed": "0:00:01.000,0:00:02.000\r\nOne\nTwo\r\r\n0:00:03.000,0:00:04.000\nThree",
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			// Reading one byte at a time splits CRLF pairs across reads
+			subtitles, err := ReadAll(NewScanner(iotest.OneByteReader(strings.NewReader(input))))
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected error: %v", err)
+			}
+			if len(subtitles) != 2 || subtitles[0].Text != "One\nTwo" || subtitles[1].Text != "Three" {
+				t.Errorf("ReadAll() = %q, want cues One\\nTwo and Three", subtitles)
+			}
+		})
+	}
+}
+
+func TestScannerPreserveWhitespace(t *testing.T) {
+	const input = "1\r\n00:00:01,000 --> 00:00:02,000\r\n    Indented  text \r\n\tTabbed\r\n\r\n"
+
+	scanner := NewSRTScanner(strings.NewReader(input))
+	scanner.PreserveWhitespace = true
+	subtitles, err := ReadAll(scanner)
+	if err != nil {
+		t.Fatalf("ReadAll() unexpected error: %v", err)
+	}
+	if want := "    Indented  text\n\tTabbed"; len(subtitles) != 1 || subtitles[0].Text != want {
+		t.Errorf("ReadAll() = %q, want one cue with text %q", subtitles, want)
+	}
+
+	subtitles, err = SRTDecoder{}.Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if want := "Indented  text\nTabbed"; len(subtitles) != 1 || subtitles[0].Text != want {
+		t.Errorf("Decode() = %q, want one cue with text %q", subtitles, want)
+	}
+
+	subtitles, _, err = SBVDecoder{PreserveWhitespace: true}.DecodeLenient(strings.NewReader("0:00:01.000,0:00:02.000\n  Left\n"))
+	if err != nil || len(subtitles) != 1 || subtitles[0].Text != "  Left" {
+		t.Errorf("DecodeLenient() = %q, %v, want one cue with text \"  Left\"", subtitles, err)
+	}
+}
@@ -0,0 +1,212 @@
+package sbv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OverlapStrategy selects how Normalize resolves cues that overlap in time.
+type OverlapStrategy int
+
+const (
+	// OverlapKeep leaves overlapping cues as they are.
+	OverlapKeep OverlapStrategy = iota
+	// OverlapTrim ends a cue when the next one starts. A cue starting at the
+	// same time as the next one is merged with it instead of vanishing.
+	OverlapTrim
+	// OverlapMerge combines overlapping cues into one cue spanning all of
+	// them, with their text lines joined.
+	OverlapMerge
+	// OverlapStack splits overlapping cues into consecutive cues that show
+	// the text of every cue active at the time, stacked in start order.
+	OverlapStack
+)
+
+// overlapStrategyNames maps strategy names used on the command line to strategies.
+var overlapStrategyNames = map[string]OverlapStrategy{
+	"keep":  OverlapKeep,
+	"trim":  OverlapTrim,
+	"merge": OverlapMerge,
+	"stack": OverlapStack,
+}
+
+// ParseOverlapStrategy returns the strategy with the given name: keep, trim,
+// merge or stack.
+func ParseOverlapStrategy(name string) (OverlapStrategy, error) {
+	strategy, ok := overlapStrategyNames[strings.ToLower(name)]
+	if !ok {
+		return OverlapKeep, fmt.Errorf("unknown overlap strategy: %s (must be one of keep, trim, merge, stack)", name)
+	}
+	return strategy, nil
+}
+
+// String returns the name of the strategy.
+func (s OverlapStrategy) String() string {
+	for name, strategy := range overlapStrategyNames {
+		if strategy == s {
+			return name
+		}
+	}
+	return fmt.Sprintf("overlap(%d)", int(s))
+}
+
+// NormalizeOptions selects the clean-up steps applied by Normalize.
+type NormalizeOptions struct {
+	// Sort orders cues by start time, keeping the input order of cues that
+	// start together.
+	Sort bool
+
+	// Overlaps selects how overlapping cues are resolved. Only consecutive
+	// cues are compared, so out-of-order input should also be sorted.
+	Overlaps OverlapStrategy
+
+	// MinGap is the shortest pause kept between consecutive cues. Cues
+	// ending closer than that to the next cue are shortened, unless that
+	// would leave them with no duration.
+	MinGap time.Duration
+}
+
+// Normalize returns a cleaned up copy of subtitles, ready to be written to
+// formats such as SRT that players expect to be ordered and non-overlapping.
+// Sorting is done first, then overlap resolution, then the minimum gap.
+func Normalize(subtitles []Subtitle, opts NormalizeOptions) []Subtitle {
+	normalized := make([]Subtitle, len(subtitles))
+	copy(normalized, subtitles)
+
+	if opts.Sort {
+		sort.SliceStable(normalized, func(i, j int) bool {
+			return normalized[i].StartTime < normalized[j].StartTime
+		})
+	}
+
+	switch opts.Overlaps {
+	case OverlapTrim:
+		normalized = trimOverlaps(normalized)
+	case OverlapMerge:
+		normalized = mergeOverlaps(normalized)
+	case OverlapStack:
+		normalized = stackOverlaps(normalized)
+	}
+
+	if opts.MinGap > 0 {
+		for i := 0; i+1 < len(normalized); i++ {
+			limit := normalized[i+1].StartTime - opts.MinGap
+			if normalized[i].EndTime > limit && limit > normalized[i].StartTime {
+				normalized[i].EndTime = limit
+			}
+		}
+	}
+
+	return normalized
+}
+
+// trimOverlaps ends every cue no later than the start of the next one.
+func trimOverlaps(subtitles []Subtitle) []Subtitle {
+	var trimmed []Subtitle
+	for _, subtitle := range subtitles {
+		if n := len(trimmed); n > 0 && subtitle.StartTime < trimmed[n-1].EndTime {
+			previous := &trimmed[n-1]
+			if subtitle.StartTime <= previous.StartTime {
+				*previous = mergeCues(*previous, subtitle)
+				continue
+			}
+			previous.EndTime = subtitle.StartTime
+		}
+		trimmed = append(trimmed, subtitle)
+	}
+	return trimmed
+}
+
+// mergeOverlaps combines every run of overlapping cues into a single cue.
+func mergeOverlaps(subtitles []Subtitle) []Subtitle {
+	var merged []Subtitle
+	for _, subtitle := range subtitles {
+		if n := len(merged); n > 0 && subtitle.StartTime < merged[n-1].EndTime {
+			merged[n-1] = mergeCues(merged[n-1], subtitle)
+			continue
+		}
+		merged = append(merged, subtitle)
+	}
+	return merged
+}
+
+// mergeCues returns a cue spanning a and b, showing the text of both.
+func mergeCues(a, b Subtitle) Subtitle {
+	return Subtitle{
+		StartTime: min(a.StartTime, b.StartTime),
+		EndTime:   max(a.EndTime, b.EndTime),
+		Text:      joinText(a.Text, b.Text),
+	}
+}
+
+// stackOverlaps splits every run of overlapping cues at each start and end
+// time, so that each piece shows the text of all cues active during it.
+func stackOverlaps(subtitles []Subtitle) []Subtitle {
+	var stacked []Subtitle
+	for start := 0; start < len(subtitles); {
+		// Find the run of cues overlapping the first one or each other
+		end := start + 1
+		runEnd := subtitles[start].EndTime
+		for end < len(subtitles) && subtitles[end].StartTime < runEnd {
+			runEnd = max(runEnd, subtitles[end].EndTime)
+			end++
+		}
+
+		if end-start == 1 {
+			stacked = append(stacked, subtitles[start])
+		} else {
+			stacked = append(stacked, stackRun(subtitles[start:end])...)
+		}
+		start = end
+	}
+	return stacked
+}
+
+// stackRun splits a run of overlapping cues into non-overlapping pieces.
+func stackRun(run []Subtitle) []Subtitle {
+	var boundaries []time.Duration
+	for _, subtitle := range run {
+		boundaries = append(boundaries, subtitle.StartTime, subtitle.EndTime)
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+
+	var pieces []Subtitle
+	for i := 0; i+1 < len(boundaries); i++ {
+		from, to := boundaries[i], boundaries[i+1]
+		if from == to {
+			continue
+		}
+
+		text := ""
+		for _, subtitle := range run {
+			if subtitle.StartTime <= from && subtitle.EndTime >= to {
+				text = joinText(text, subtitle.Text)
+			}
+		}
+		if text == "" {
+			continue
+		}
+
+		// Extend the previous piece rather than repeating the same text
+		if n := len(pieces); n > 0 && pieces[n-1].EndTime == from && pieces[n-1].Text == text {
+			pieces[n-1].EndTime = to
+			continue
+		}
+		pieces = append(pieces, Subtitle{StartTime: from, EndTime: to, Text: text})
+	}
+	return pieces
+}
+
+// joinText joins two cue texts on separate lines, skipping empty ones.
+func joinText(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "\n" + b
+	}
+}
@@ -0,0 +1,117 @@
+package sbv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cue builds a subtitle from start and end times in seconds.
+func cue(start, end float64, text string) Subtitle {
+	return Subtitle{
+		StartTime: time.Duration(start * float64(time.Second)),
+		EndTime:   time.Duration(end * float64(time.Second)),
+		Text:      text,
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtitles []Subtitle
+		opts      NormalizeOptions
+		want      []Subtitle
+	}{
+		{
+			name:      "no options",
+			subtitles: []Subtitle{cue(5, 6, "B"), cue(1, 7, "A")},
+			want:      []Subtitle{cue(5, 6, "B"), cue(1, 7, "A")},
+		},
+		{
+			name:      "sort is stable",
+			subtitles: []Subtitle{cue(5, 6, "C"), cue(1, 2, "A"), cue(1, 3, "B")},
+			opts:      NormalizeOptions{Sort: true},
+			want:      []Subtitle{cue(1, 2, "A"), cue(1, 3, "B"), cue(5, 6, "C")},
+		},
+		{
+			name:      "trim",
+			subtitles: []Subtitle{cue(1, 4, "A"), cue(3, 5, "B"), cue(6, 7, "C")},
+			opts:      NormalizeOptions{Overlaps: OverlapTrim},
+			want:      []Subtitle{cue(1, 3, "A"), cue(3, 5, "B"), cue(6, 7, "C")},
+		},
+		{
+			name:      "trim merges cues starting together",
+			subtitles: []Subtitle{cue(1, 4, "A"), cue(1, 3, "B")},
+			opts:      NormalizeOptions{Overlaps: OverlapTrim},
+			want:      []Subtitle{cue(1, 4, "A\nB")},
+		},
+		{
+			name:      "merge",
+			subtitles: []Subtitle{cue(1, 4, "A"), cue(3, 5, "B"), cue(4.5, 6, "C"), cue(7, 8, "D")},
+			opts:      NormalizeOptions{Overlaps: OverlapMerge},
+			want:      []Subtitle{cue(1, 6, "A\nB\nC"), cue(7, 8, "D")},
+		},
+		{
+			name:      "stack",
+			subtitles: []Subtitle{cue(1, 4, "A"), cue(2, 3, "B"), cue(3.5, 5, "C"), cue(6, 7, "D")},
+			opts:      NormalizeOptions{Overlaps: OverlapStack},
+			want: []Subtitle{
+				cue(1, 2, "A"),
+				cue(2, 3, "A\nB"),
+				cue(3, 3.5, "A"),
+				cue(3.5, 4, "A\nC"),
+				cue(4, 5, "C"),
+				cue(6, 7, "D"),
+			},
+		},
+		{
+			name:      "sort before resolving overlaps",
+			subtitles: []Subtitle{cue(3, 5, "B"), cue(1, 4, "A")},
+			opts:      NormalizeOptions{Sort: true, Overlaps: OverlapTrim},
+			want:      []Subtitle{cue(1, 3, "A"), cue(3, 5, "B")},
+		},
+		{
+			name:      "minimum gap",
+			subtitles: []Subtitle{cue(1, 3, "A"), cue(3, 5, "B"), cue(5.05, 6, "C"), cue(6.05, 9, "D")},
+			opts:      NormalizeOptions{MinGap: 100 * time.Millisecond},
+			want:      []Subtitle{cue(1, 2.9, "A"), cue(3, 4.95, "B"), cue(5.05, 5.95, "C"), cue(6.05, 9, "D")},
+		},
+		{
+			name:      "minimum gap keeps cues from vanishing",
+			subtitles: []Subtitle{cue(1, 1.05, "A"), cue(1.1, 2, "B")},
+			opts:      NormalizeOptions{MinGap: 100 * time.Millisecond},
+			want:      []Subtitle{cue(1, 1.05, "A"), cue(1.1, 2, "B")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]Subtitle(nil), tt.subtitles...)
+			got := Normalize(tt.subtitles, tt.opts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.subtitles, input) {
+				t.Errorf("Normalize() modified its input")
+			}
+		})
+	}
+}
+
+func TestParseOverlapStrategy(t *testing.T) {
+	for _, name := range []string{"keep", "trim", "merge", "STACK"} {
+		strategy, err := ParseOverlapStrategy(name)
+		if err != nil {
+			t.Errorf("ParseOverlapStrategy(%q) unexpected error: %v", name, err)
+			continue
+		}
+		if got := strategy.String(); got != strings.ToLower(name) {
+			t.Errorf("ParseOverlapStrategy(%q).String() = %q", name, got)
+		}
+	}
+
+	if _, err := ParseOverlapStrategy("shuffle"); err == nil {
+		t.Errorf("ParseOverlapStrategy() expected error for unknown name, got nil")
+	}
+}
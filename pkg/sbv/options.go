@@ -0,0 +1,111 @@
+package sbv
+
+import (
+	"io"
+	"strings"
+)
+
+// Option configures a DefaultConverter created by NewConverter.
+type Option func(c *DefaultConverter)
+
+// TimeFormat controls how timestamps are written.
+type TimeFormat struct {
+	// SRTDecimalSeparator separates seconds from milliseconds in SRT
+	// timestamps. The zero value writes the standard ','; some tools expect '.'.
+	SRTDecimalSeparator rune
+
+	// SBVHourDigits is the least number of digits written for the hours of
+	// SBV timestamps. Zero or one writes "0:00:01.000", two "00:00:01.000".
+	SBVHourDigits int
+}
+
+// WithLenient makes ParseFromReader and ParseSRTFromReader skip or repair
+// malformed cues instead of failing. The problems found are discarded; use
+// the Lenient parse methods to receive them.
+func WithLenient(lenient bool) Option {
+	return func(c *DefaultConverter) {
+		c.lenient = lenient
+	}
+}
+
+// WithPreserveWhitespace keeps leading and interior whitespace in parsed cue
+// text. Only trailing whitespace is removed.
+func WithPreserveWhitespace(preserve bool) Option {
+	return func(c *DefaultConverter) {
+		c.preserveWhitespace = preserve
+	}
+}
+
+// WithLineEnding sets the line ending of written output. The default is LF.
+func WithLineEnding(ending LineEnding) Option {
+	return func(c *DefaultConverter) {
+		c.lineEnding = ending
+	}
+}
+
+// WithStartIndex sets the number of the first SRT cue and WebVTT cue
+// identifier. The default is 1.
+func WithStartIndex(index int) Option {
+	return func(c *DefaultConverter) {
+		c.startIndex = index
+	}
+}
+
+// WithInputEncoding declares the character encoding of parsed input. The
+// default, EncodingAuto, detects it from the start of the input.
+func WithInputEncoding(encoding Encoding) Option {
+	return func(c *DefaultConverter) {
+		c.inputEncoding = encoding
+	}
+}
+
+// WithOutputEncoding sets the character encoding of output written to files
+// and writers. Strings returned by the Convert methods are always UTF-8.
+// The default is UTF-8 without a byte order mark.
+func WithOutputEncoding(encoding Encoding) Option {
+	return func(c *DefaultConverter) {
+		c.outputEncoding = encoding
+	}
+}
+
+// WithTimeFormat sets how timestamps are written.
+func WithTimeFormat(format TimeFormat) Option {
+	return func(c *DefaultConverter) {
+		c.timeFormat = format
+	}
+}
+
+// textReader decodes reader from the converter's input encoding. Without
+// one, a reader that is already a TextReader is used as it is.
+func (c *DefaultConverter) textReader(reader io.Reader) io.Reader {
+	if c.inputEncoding == EncodingAuto {
+		return autoTextReader(reader)
+	}
+	return NewTextReader(reader, c.inputEncoding)
+}
+
+// textWriter encodes text for writer in the converter's output encoding and
+// line ending.
+func (c *DefaultConverter) textWriter(writer io.Writer) io.Writer {
+	if c.outputEncoding != EncodingAuto && c.outputEncoding != EncodingUTF8 {
+		writer = NewTextWriter(writer, c.outputEncoding)
+	}
+	if c.lineEnding != LF {
+		writer = NewLineEndingWriter(writer, c.lineEnding)
+	}
+	return writer
+}
+
+// withLineEnding returns text with its line feeds replaced by the
+// converter's line ending.
+func (c *DefaultConverter) withLineEnding(text string) string {
+	if c.lineEnding == LF {
+		return text
+	}
+
+	var result strings.Builder
+	result.Grow(len(text) + strings.Count(text, "\n")*len(c.lineEnding))
+	// Writing to a strings.Builder cannot fail
+	_, _ = io.WriteString(NewLineEndingWriter(&result, c.lineEnding), text)
+	return result.String()
+}
@@ -0,0 +1,99 @@
+package sbv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewConverterDefaults(t *testing.T) {
+	subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hello"}}
+
+	want := "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n"
+	if got := NewConverter().ConvertToSRT(subtitles); got != want {
+		t.Errorf("ConvertToSRT() = %q, want %q", got, want)
+	}
+}
+
+func TestConverterOutputOptions(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: time.Second, EndTime: 2 * time.Second, Text: "One\nline two"},
+		{StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "Two"},
+	}
+
+	converter := NewConverter(
+		WithStartIndex(0),
+		WithLineEnding(CRLF),
+		WithTimeFormat(TimeFormat{SRTDecimalSeparator: '.', SBVHourDigits: 2}),
+	)
+
+	wantSRT := "0\r\n00:00:01.000 --> 00:00:02.000\r\nOne\r\nline two\r\n\r\n" +
+		"1\r\n00:00:03.000 --> 00:00:04.000\r\nTwo\r\n\r\n"
+	if got := converter.ConvertToSRT(subtitles); got != wantSRT {
+		t.Errorf("ConvertToSRT() = %q, want %q", got, wantSRT)
+	}
+
+	var buf bytes.Buffer
+	if err := converter.WriteToWriter(subtitles, &buf); err != nil {
+		t.Fatalf("WriteToWriter() unexpected error: %v", err)
+	}
+	if buf.String() != wantSRT {
+		t.Errorf("WriteToWriter() = %q, want %q", buf.String(), wantSRT)
+	}
+
+	wantSBV := "00:00:01.000,00:00:02.000\r\nOne\r\nline two\r\n\r\n00:00:03.000,00:00:04.000\r\nTwo\r\n"
+	if got := converter.ConvertToSBV(subtitles); got != wantSBV {
+		t.Errorf("ConvertToSBV() = %q, want %q", got, wantSBV)
+	}
+
+	vtt := converter.ConvertToVTT(subtitles, VTTOptions{CueIdentifiers: true})
+	if !strings.HasPrefix(vtt, "WEBVTT\r\n\r\n0\r\n00:00:01.000") {
+		t.Errorf("ConvertToVTT() = %q, want CRLF output with cue identifiers from 0", vtt)
+	}
+}
+
+func TestConverterOutputEncoding(t *testing.T) {
+	subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Café"}}
+
+	var buf bytes.Buffer
+	if err := NewConverter(WithOutputEncoding(EncodingUTF16LE)).WriteToWriter(subtitles, &buf); err != nil {
+		t.Fatalf("WriteToWriter() unexpected error: %v", err)
+	}
+	if want := append([]byte{0xFF, 0xFE}, utf16Bytes("1\n00:00:01,000 --> 00:00:02,000\nCafé\n\n", false)...); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteToWriter() wrote % x, want % x", buf.Bytes(), want)
+	}
+
+	// The UTF-16 output parses back with the encoding detected
+	parsed, err := NewConverter().ParseSRTFromReader(&buf)
+	if err != nil || len(parsed) != 1 || parsed[0].Text != "Café" {
+		t.Errorf("ParseSRTFromReader() = %q, %v, want one cue with text Café", parsed, err)
+	}
+}
+
+func TestConverterParseOptions(t *testing.T) {
+	const input = "0:00:01.000,0:00:02.000\n  Indented\n\nbad,line:\nSkipped\n\n0:00:03.000,0:00:04.000\nCaf\xe9\n"
+
+	if _, err := NewConverter().ParseFromReader(strings.NewReader(input)); err == nil {
+		t.Errorf("ParseFromReader() expected error for malformed cue, got nil")
+	}
+
+	converter := NewConverter(
+		WithLenient(true),
+		WithPreserveWhitespace(true),
+		WithInputEncoding(EncodingWindows1252),
+	)
+	subtitles, err := converter.ParseFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseFromReader() unexpected error: %v", err)
+	}
+	if len(subtitles) != 2 || subtitles[0].Text != "  Indented" || subtitles[1].Text != "Café" {
+		t.Errorf("ParseFromReader() = %q, want cues \"  Indented\" and Café", subtitles)
+	}
+
+	// A declared encoding is not second-guessed by detection
+	subtitles, err = NewConverter(WithInputEncoding(EncodingUTF8)).ParseFromReader(strings.NewReader("0:00:01.000,0:00:02.000\nCaf\xe9\n"))
+	if err != nil || len(subtitles) != 1 || subtitles[0].Text != "Caf\xe9" {
+		t.Errorf("ParseFromReader() with declared UTF-8 = %q, %v, want the bytes unchanged", subtitles, err)
+	}
+}
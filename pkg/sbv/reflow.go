@@ -0,0 +1,252 @@
+package sbv
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ReflowOptions controls how Reflow re-wraps cue text.
+type ReflowOptions struct {
+	// MaxLineWidth is the widest a line may be, in columns as counted by
+	// DisplayWidth. Zero leaves the text unchanged.
+	MaxLineWidth int
+
+	// MaxLines is the most lines a cue may have before SplitCues splits it.
+	// It has no effect without SplitCues, as Reflow never drops text to fit
+	// a cue into fewer lines. Zero allows any number.
+	MaxLines int
+
+	// Balance makes the lines of a cue as even in width as possible instead
+	// of filling each line before starting the next.
+	Balance bool
+
+	// SplitCues splits cues that need more than MaxLines lines into several
+	// consecutive cues. Each gets a share of the original duration
+	// proportional to the width of its text.
+	SplitCues bool
+}
+
+// Reflow returns a copy of subtitles with the text of every cue re-wrapped
+// to the given line width. Existing line breaks are replaced. Words are never
+// broken, but text without spaces, such as Chinese or Japanese, may be broken
+// between any two wide characters.
+func Reflow(subtitles []Subtitle, opts ReflowOptions) []Subtitle {
+	if opts.MaxLineWidth <= 0 {
+		return append([]Subtitle(nil), subtitles...)
+	}
+
+	var reflowed []Subtitle
+	for _, subtitle := range subtitles {
+		units := splitUnits(subtitle.Text)
+		if len(units) == 0 {
+			reflowed = append(reflowed, subtitle)
+			continue
+		}
+
+		breaks := wrapBreaks(units, opts.MaxLineWidth, opts.Balance)
+		if !opts.SplitCues || opts.MaxLines <= 0 || len(breaks) < opts.MaxLines {
+			subtitle.Text = strings.Join(layoutLines(units, breaks), "\n")
+			reflowed = append(reflowed, subtitle)
+			continue
+		}
+
+		reflowed = append(reflowed, splitCue(subtitle, units, breaks, opts)...)
+	}
+
+	return reflowed
+}
+
+// textUnit is a piece of text that is never broken across lines: a word, or
+// a single wide character with any combining marks that follow it.
+type textUnit struct {
+	text  string
+	width int
+	wide  bool
+
+	// spaced is set if the unit is separated from the previous one by a
+	// space when both are on the same line.
+	spaced bool
+}
+
+// splitUnits breaks text into units. A line break between two wide
+// characters is dropped rather than turned into a space, since such
+// scripts are written without spaces.
+func splitUnits(text string) []textUnit {
+	var (
+		units        []textUnit
+		current      strings.Builder
+		width        int
+		spaced       bool
+		newlineOnly  = true
+		hasSeparator bool
+	)
+
+	flush := func(wide bool) {
+		if current.Len() == 0 {
+			return
+		}
+		unit := textUnit{text: current.String(), width: width, wide: wide, spaced: spaced}
+		if n := len(units); n > 0 && hasSeparator && newlineOnly && units[n-1].wide && wide {
+			unit.spaced = false
+		}
+		units = append(units, unit)
+		current.Reset()
+		width, spaced, newlineOnly, hasSeparator = 0, false, true, false
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			flush(false)
+			if len(units) > 0 {
+				spaced = true
+				hasSeparator = true
+				if r != '\n' && r != '\r' {
+					newlineOnly = false
+				}
+			}
+		case runeWidth(r) == 2:
+			flush(false)
+			current.WriteRune(r)
+			width = 2
+			// Keep combining marks with their base character
+			for i+1 < len(runes) && isZeroWidth(runes[i+1]) {
+				i++
+				current.WriteRune(runes[i])
+			}
+			flush(true)
+		default:
+			current.WriteRune(r)
+			width += runeWidth(r)
+		}
+	}
+	flush(false)
+
+	return units
+}
+
+// lineWidth returns the width of units[from:to] laid out on one line.
+func lineWidth(units []textUnit, from, to int) int {
+	width := 0
+	for i := from; i < to; i++ {
+		if i > from && units[i].spaced {
+			width++
+		}
+		width += units[i].width
+	}
+	return width
+}
+
+// greedyBreaks fills each line with as many units as fit in maxWidth and
+// returns the index of the first unit of every line after the first.
+func greedyBreaks(units []textUnit, maxWidth int) []int {
+	var breaks []int
+	start := 0
+	for i := 1; i < len(units); i++ {
+		if lineWidth(units, start, i+1) > maxWidth {
+			breaks = append(breaks, i)
+			start = i
+		}
+	}
+	return breaks
+}
+
+// wrapBreaks lays units out on lines no wider than maxWidth and returns the
+// line breaks. With balance, the narrowest width that still needs the same
+// number of lines is used, which evens out the line lengths.
+func wrapBreaks(units []textUnit, maxWidth int, balance bool) []int {
+	breaks := greedyBreaks(units, maxWidth)
+	if !balance || len(breaks) == 0 {
+		return breaks
+	}
+
+	lo, hi := 1, maxWidth
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if len(greedyBreaks(units, mid)) <= len(breaks) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return greedyBreaks(units, lo)
+}
+
+// layoutLines joins units into lines, starting a new line at every break.
+func layoutLines(units []textUnit, breaks []int) []string {
+	lines := make([]string, 0, len(breaks)+1)
+	start := 0
+	for n := 0; n <= len(breaks); n++ {
+		end := len(units)
+		if n < len(breaks) {
+			end = breaks[n]
+		}
+
+		var line strings.Builder
+		for i := start; i < end; i++ {
+			if i > start && units[i].spaced {
+				line.WriteByte(' ')
+			}
+			line.WriteString(units[i].text)
+		}
+		lines = append(lines, line.String())
+		start = end
+	}
+	return lines
+}
+
+// splitCue splits a cue whose text wraps to too many lines into consecutive
+// cues of at most MaxLines lines each, dividing the duration in proportion
+// to the width of each part.
+func splitCue(subtitle Subtitle, units []textUnit, breaks []int, opts ReflowOptions) []Subtitle {
+	lineStarts := append([]int{0}, breaks...)
+
+	var (
+		parts  []string
+		widths []int
+		total  int
+	)
+	for line := 0; line < len(lineStarts); line += opts.MaxLines {
+		from := lineStarts[line]
+		to := len(units)
+		if next := line + opts.MaxLines; next < len(lineStarts) {
+			to = lineStarts[next]
+		}
+
+		part := units[from:to]
+		var partBreaks []int
+		if opts.Balance {
+			partBreaks = wrapBreaks(part, opts.MaxLineWidth, true)
+		} else {
+			for _, b := range breaks {
+				if b > from && b < to {
+					partBreaks = append(partBreaks, b-from)
+				}
+			}
+		}
+
+		width := max(lineWidth(part, 0, len(part)), 1)
+		parts = append(parts, strings.Join(layoutLines(part, partBreaks), "\n"))
+		widths = append(widths, width)
+		total += width
+	}
+
+	duration := subtitle.EndTime - subtitle.StartTime
+	cues := make([]Subtitle, len(parts))
+	done := 0
+	start := subtitle.StartTime
+	for i, part := range parts {
+		done += widths[i]
+		end := subtitle.StartTime + time.Duration(float64(duration)*float64(done)/float64(total))
+		if i == len(parts)-1 {
+			end = subtitle.EndTime
+		}
+		cues[i] = Subtitle{StartTime: start, EndTime: end, Text: part}
+		start = end
+	}
+
+	return cues
+}
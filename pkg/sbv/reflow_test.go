@@ -0,0 +1,95 @@
+package sbv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReflow(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts ReflowOptions
+		want string
+	}{
+		{
+			name: "disabled",
+			text: "Keep\nthese breaks",
+			opts: ReflowOptions{},
+			want: "Keep\nthese breaks",
+		},
+		{
+			name: "arbitrary breaks are joined",
+			text: "so we\nwent to the\nstore",
+			opts: ReflowOptions{MaxLineWidth: 42},
+			want: "so we went to the store",
+		},
+		{
+			name: "greedy",
+			text: "the quick brown fox jumps over the lazy dog",
+			opts: ReflowOptions{MaxLineWidth: 30},
+			want: "the quick brown fox jumps over\nthe lazy dog",
+		},
+		{
+			name: "balanced",
+			text: "the quick brown fox jumps over the lazy dog",
+			opts: ReflowOptions{MaxLineWidth: 30, Balance: true},
+			want: "the quick brown fox\njumps over the lazy dog",
+		},
+		{
+			name: "long word is not broken",
+			text: "a supercalifragilistic word",
+			opts: ReflowOptions{MaxLineWidth: 10},
+			want: "a\nsupercalifragilistic\nword",
+		},
+		{
+			name: "cjk breaks between characters by width",
+			text: "今日は\nいい天気ですね",
+			opts: ReflowOptions{MaxLineWidth: 12, Balance: true},
+			want: "今日はいい\n天気ですね",
+		},
+		{
+			name: "too many lines are kept without splitting",
+			text: "one two three four five six",
+			opts: ReflowOptions{MaxLineWidth: 9, MaxLines: 2},
+			want: "one two\nthree\nfour five\nsix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: tt.text}}
+			got := Reflow(subtitles, tt.opts)
+			if len(got) != 1 || got[0].Text != tt.want {
+				t.Errorf("Reflow() = %q, want %q", got, tt.want)
+			}
+			if subtitles[0].Text != tt.text {
+				t.Errorf("Reflow() modified its input")
+			}
+		})
+	}
+}
+
+func TestReflowSplitCues(t *testing.T) {
+	subtitles := []Subtitle{
+		{
+			StartTime: 10 * time.Second,
+			EndTime:   16 * time.Second,
+			Text:      "aaaa bbbb cccc dddd eeee ffff",
+		},
+		{StartTime: 20 * time.Second, EndTime: 21 * time.Second, Text: "short"},
+	}
+
+	got := Reflow(subtitles, ReflowOptions{MaxLineWidth: 9, MaxLines: 2, SplitCues: true})
+	// The first part has 19 of the 28 columns of text
+	split := 10*time.Second + 6*time.Second*19/28
+	want := []Subtitle{
+		{StartTime: 10 * time.Second, EndTime: split, Text: "aaaa bbbb\ncccc dddd"},
+		{StartTime: split, EndTime: 16 * time.Second, Text: "eeee ffff"},
+		{StartTime: 20 * time.Second, EndTime: 21 * time.Second, Text: "short"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Reflow() = %v, want %v", got, want)
+	}
+}
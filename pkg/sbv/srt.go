@@ -0,0 +1,174 @@
+package sbv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSRTFromFile reads and parses an SRT file from the given file path.
+func (c *DefaultConverter) ParseSRTFromFile(filename string) ([]Subtitle, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			// Log the error but don't override the main error
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+
+	return c.ParseSRTFromReader(file)
+}
+
+// ParseSRTFromReader reads and parses SRT content from an io.Reader.
+// Sequence numbers are discarded; cues are returned in input order.
+// With WithLenient, malformed cues are skipped or repaired.
+func (c *DefaultConverter) ParseSRTFromReader(reader io.Reader) ([]Subtitle, error) {
+	scanner := c.newSRTScanner(reader)
+	scanner.Lenient = c.lenient
+	return ReadAll(scanner)
+}
+
+// ParseSRTFromReaderLenient reads and parses SRT content from an io.Reader,
+// skipping or repairing malformed cues instead of failing. Every problem is
+// reported as a Diagnostic; the error is only set if the input cannot be read.
+func (c *DefaultConverter) ParseSRTFromReaderLenient(reader io.Reader) ([]Subtitle, []Diagnostic, error) {
+	scanner := c.newSRTScanner(reader)
+	scanner.Lenient = true
+
+	subtitles, err := ReadAll(scanner)
+	return subtitles, scanner.Diagnostics(), err
+}
+
+// newSRTScanner creates an SRTScanner reading SRT content from reader in the
+// converter's input encoding and whitespace handling.
+func (c *DefaultConverter) newSRTScanner(reader io.Reader) *SRTScanner {
+	scanner := NewSRTScanner(c.textReader(reader))
+	scanner.PreserveWhitespace = c.preserveWhitespace
+	return scanner
+}
+
+// ConvertToSBV converts parsed subtitles to SBV format string.
+func (c *DefaultConverter) ConvertToSBV(subtitles []Subtitle) string {
+	var result strings.Builder
+	result.Grow(len(subtitles) * 100) // Pre-allocate approximate capacity
+
+	for i, subtitle := range subtitles {
+		c.writeSBVCue(&result, i > 0, subtitle)
+	}
+
+	return c.withLineEnding(result.String())
+}
+
+// writeSBVCue formats a single SBV cue, preceded by a blank line when separate is set.
+func (c *DefaultConverter) writeSBVCue(buf cueBuffer, separate bool, subtitle Subtitle) {
+	if separate {
+		buf.WriteByte('\n')
+	}
+
+	// SBV timestamp format: H:MM:SS.mmm,H:MM:SS.mmm
+	buf.WriteString(c.formatSBVTime(subtitle.StartTime))
+	buf.WriteByte(',')
+	buf.WriteString(c.formatSBVTime(subtitle.EndTime))
+	buf.WriteByte('\n')
+
+	// Subtitle text
+	buf.WriteString(subtitle.Text)
+	buf.WriteByte('\n')
+}
+
+// WriteSBVToFile converts subtitles and writes them directly to an SBV file.
+func (c *DefaultConverter) WriteSBVToFile(subtitles []Subtitle, filename string) error {
+	return WriteFileAtomic(filename, func(writer io.Writer) error {
+		return c.WriteSBVToWriter(subtitles, writer)
+	})
+}
+
+// WriteSBVToWriter converts subtitles and writes them to an io.Writer in SBV format.
+// Each cue is written as soon as it is formatted.
+func (c *DefaultConverter) WriteSBVToWriter(subtitles []Subtitle, writer io.Writer) error {
+	return writeCues(newSBVWriter(c.textWriter(writer), c), subtitles)
+}
+
+// formatSBVTime formats a time.Duration to SBV timestamp format (H:MM:SS.mmm).
+// Hours are zero-padded to the converter's SBV hour digits.
+func (c *DefaultConverter) formatSBVTime(duration time.Duration) string {
+	hours, minutes, seconds, milliseconds := splitDuration(duration)
+	return fmt.Sprintf("%0*d:%02d:%02d.%03d", max(c.timeFormat.SBVHourDigits, 1), hours, minutes, seconds, milliseconds)
+}
+
+// srtTiming describes SRT timing lines for the cue scanners.
+// Sequence numbers are expected between cues.
+func (c *DefaultConverter) srtTiming() cueTiming {
+	return cueTiming{
+		isTiming: c.isSRTTimestampLine,
+		isFiller: c.isSRTSequenceLine,
+		parse:    c.parseSRTTimestamps,
+		column:   c.srtTimestampErrorColumn,
+	}
+}
+
+// isSRTSequenceLine checks if a line is an SRT sequence number.
+func (c *DefaultConverter) isSRTSequenceLine(line string) bool {
+	_, err := strconv.Atoi(line)
+	return err == nil
+}
+
+// srtTimestampErrorColumn returns the 1-based column of the first malformed
+// time in an SRT timestamp line.
+func (c *DefaultConverter) srtTimestampErrorColumn(timestampLine string) int {
+	arrow := strings.Index(timestampLine, "-->")
+	if arrow < 0 {
+		return 1
+	}
+	if _, err := c.parseSRTTime(strings.TrimSpace(timestampLine[:arrow])); err != nil {
+		return 1
+	}
+	end := timestampLine[arrow+3:]
+	return arrow + 4 + len(end) - len(strings.TrimLeft(end, " \t"))
+}
+
+// isSRTTimestampLine checks if a line contains the SRT timing arrow.
+func (c *DefaultConverter) isSRTTimestampLine(line string) bool {
+	return strings.Contains(line, "-->")
+}
+
+// parseSRTTimestamps parses SRT timestamp format "HH:MM:SS,mmm --> HH:MM:SS,mmm".
+// Any trailing position coordinates after the end time are ignored.
+func (c *DefaultConverter) parseSRTTimestamps(timestampLine string) (time.Duration, time.Duration, error) {
+	parts := strings.Split(timestampLine, "-->")
+	if len(parts) != 2 {
+		return 0, 0, errorf(ErrBadTimestamp, "invalid timestamp format: %s", timestampLine)
+	}
+
+	startTime, err := c.parseSRTTime(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse start time: %w", err)
+	}
+
+	endFields := strings.Fields(parts[1])
+	if len(endFields) == 0 {
+		return 0, 0, errorf(ErrBadTimestamp, "invalid timestamp format: %s", timestampLine)
+	}
+
+	endTime, err := c.parseSRTTime(endFields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse end time: %w", err)
+	}
+
+	return startTime, endTime, nil
+}
+
+// parseSRTTime parses a time string in format "HH:MM:SS,mmm".
+// A dot is also accepted as the millisecond separator, as written by some tools.
+func (c *DefaultConverter) parseSRTTime(timeStr string) (time.Duration, error) {
+	if strings.Count(timeStr, ",") > 1 {
+		return 0, errorf(ErrBadTime, "invalid time format: %s", timeStr)
+	}
+	return c.parseTime(strings.Replace(timeStr, ",", ".", 1))
+}
@@ -0,0 +1,229 @@
+package sbv
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSRTFromReader(t *testing.T) {
+	converter := NewConverter()
+
+	srtContent := `1
+00:00:01,000 --> 00:00:04,000
+This is a sample SBV subtitle file
+used for testing purposes.
+
+2
+00:00:05,500 --> 00:00:08,200 X1:40 X2:600 Y1:20 Y2:50
+SBV files use this timestamp format
+and are commonly used by YouTube.
+
+3
+00:00:10.000 --> 00:00:12.500
+This will be converted to SRT format.
+`
+
+	subtitles, err := converter.ParseSRTFromReader(strings.NewReader(srtContent))
+	if err != nil {
+		t.Fatalf("ParseSRTFromReader() error: %v", err)
+	}
+
+	expected := []Subtitle{
+		{
+			StartTime: 1 * time.Second,
+			EndTime:   4 * time.Second,
+			Text:      "This is a sample SBV subtitle file\nused for testing purposes.",
+		},
+		{
+			StartTime: 5*time.Second + 500*time.Millisecond,
+			EndTime:   8*time.Second + 200*time.Millisecond,
+			Text:      "SBV files use this timestamp format\nand are commonly used by YouTube.",
+		},
+		{
+			StartTime: 10 * time.Second,
+			EndTime:   12*time.Second + 500*time.Millisecond,
+			Text:      "This will be converted to SRT format.",
+		},
+	}
+
+	if len(subtitles) != len(expected) {
+		t.Fatalf("ParseSRTFromReader() got %d subtitles, want %d", len(subtitles), len(expected))
+	}
+
+	for i := range expected {
+		if subtitles[i] != expected[i] {
+			t.Errorf("subtitle %d = %+v, want %+v", i, subtitles[i], expected[i])
+		}
+	}
+}
+
+func TestParseSRTFromReaderErrors(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "missing end time",
+			content: "1\n00:00:01,000 -->\nText\n",
+		},
+		{
+			name:    "multiple arrows",
+			content: "1\n00:00:01,000 --> 00:00:02,000 --> 00:00:03,000\nText\n",
+		},
+		{
+			name:    "invalid start time",
+			content: "1\n00:xx:01,000 --> 00:00:02,000\nText\n",
+		},
+		{
+			name:    "multiple commas",
+			content: "1\n00:00:01,000,000 --> 00:00:02,000\nText\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := converter.ParseSRTFromReader(strings.NewReader(tt.content)); err == nil {
+				t.Errorf("ParseSRTFromReader() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestConvertToSBV(t *testing.T) {
+	converter := NewConverter()
+
+	subtitles := []Subtitle{
+		{
+			StartTime: 1 * time.Second,
+			EndTime:   4 * time.Second,
+			Text:      "First subtitle",
+		},
+		{
+			StartTime: 1*time.Hour + 5*time.Second + 500*time.Millisecond,
+			EndTime:   1*time.Hour + 8*time.Second + 200*time.Millisecond,
+			Text:      "Second subtitle\nwith multiple lines",
+		},
+	}
+
+	result := converter.ConvertToSBV(subtitles)
+
+	expected := `0:00:01.000,0:00:04.000
+First subtitle
+
+1:00:05.500,1:00:08.200
+Second subtitle
+with multiple lines
+`
+
+	if result != expected {
+		t.Errorf("ConvertToSBV() = %q, want %q", result, expected)
+	}
+}
+
+func TestFormatSBVTime(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{
+			name:     "zero duration",
+			duration: 0,
+			expected: "0:00:00.000",
+		},
+		{
+			name:     "1 second",
+			duration: 1 * time.Second,
+			expected: "0:00:01.000",
+		},
+		{
+			name:     "1 hour 30 minutes 15.5 seconds",
+			duration: 1*time.Hour + 30*time.Minute + 15*time.Second + 500*time.Millisecond,
+			expected: "1:30:15.500",
+		},
+		{
+			name:     "24 hours",
+			duration: 24 * time.Hour,
+			expected: "24:00:00.000",
+		},
+		{
+			name:     "100 hours",
+			duration: 100*time.Hour + 1*time.Second,
+			expected: "100:00:01.000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := converter.formatSBVTime(tt.duration)
+			if result != tt.expected {
+				t.Errorf("formatSBVTime() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSRTRoundTrip(t *testing.T) {
+	converter := NewConverter()
+
+	sbvContent, err := os.ReadFile("../../testdata/sample.sbv")
+	if err != nil {
+		t.Fatalf("Failed to read sample SBV: %v", err)
+	}
+
+	original, err := converter.ParseFromReader(strings.NewReader(string(sbvContent)))
+	if err != nil {
+		t.Fatalf("ParseFromReader() error: %v", err)
+	}
+
+	srt := converter.ConvertToSRT(original)
+	parsed, err := converter.ParseSRTFromReader(strings.NewReader(srt))
+	if err != nil {
+		t.Fatalf("ParseSRTFromReader() error: %v", err)
+	}
+
+	sbv := converter.ConvertToSBV(parsed)
+	if sbv != string(sbvContent) {
+		t.Errorf("round trip SBV = %q, want %q", sbv, string(sbvContent))
+	}
+}
+
+func TestWriteSBVToFile(t *testing.T) {
+	converter := NewConverter()
+
+	tmpFile, err := os.CreateTemp("", "test_output_*.sbv")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	filename := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(filename)
+
+	subtitles := []Subtitle{
+		{
+			StartTime: 1 * time.Second,
+			EndTime:   4 * time.Second,
+			Text:      "Single subtitle",
+		},
+	}
+
+	if err := converter.WriteSBVToFile(subtitles, filename); err != nil {
+		t.Fatalf("WriteSBVToFile() unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	expected := "0:00:01.000,0:00:04.000\nSingle subtitle\n"
+	if string(content) != expected {
+		t.Errorf("WriteSBVToFile() wrote %q, want %q", string(content), expected)
+	}
+}
@@ -0,0 +1,491 @@
+package sbv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CueReader reads subtitles one cue at a time.
+type CueReader interface {
+	// Next returns the next subtitle entry, or io.EOF when the input is exhausted.
+	Next() (Subtitle, error)
+}
+
+// CueWriter writes subtitles one cue at a time.
+type CueWriter interface {
+	// WriteCue serializes a single subtitle entry and writes it out immediately.
+	WriteCue(subtitle Subtitle) error
+
+	// Flush writes anything the format still owes the output, such as a
+	// header for an empty file. It must be called once after the last cue.
+	Flush() error
+}
+
+// cueBuffer is the subset of strings.Builder and bytes.Buffer used to format cues.
+type cueBuffer interface {
+	WriteString(s string) (int, error)
+	WriteByte(c byte) error
+}
+
+// lineReader reads trimmed lines and keeps track of the current line number.
+type lineReader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	// preserve keeps the leading whitespace of cue text lines.
+	preserve bool
+}
+
+// newLineReader returns a lineReader for reader, decoding it to UTF-8 from
+// the encoding detected at its start unless it is already a TextReader. Lines
+// may end in "\n", "\r\n" or "\r".
+func newLineReader(reader io.Reader) lineReader {
+	scanner := bufio.NewScanner(autoTextReader(reader))
+	scanner.Split(scanLines)
+	return lineReader{scanner: scanner}
+}
+
+// next returns the next trimmed line, or false at the end of input or on a read error.
+func (r *lineReader) next() (string, bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.line++
+	return strings.TrimSpace(r.scanner.Text()), true
+}
+
+// readText reads cue text lines up to the next blank line or the end of input.
+// Lines are trimmed unless whitespace is preserved, in which case only
+// trailing whitespace is removed.
+func (r *lineReader) readText() string {
+	var textLines []string
+	for {
+		line, ok := r.next()
+		if !ok || line == "" {
+			break
+		}
+		if r.preserve {
+			line = strings.TrimRightFunc(r.scanner.Text(), unicode.IsSpace)
+		}
+		textLines = append(textLines, line)
+	}
+	return strings.Join(textLines, "\n")
+}
+
+// end returns the error that stopped the reader, or io.EOF if the input was exhausted.
+func (r *lineReader) end() error {
+	if err := r.scanner.Err(); err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+	return io.EOF
+}
+
+// Scanner reads SBV cues one at a time. Only the current cue is held in
+// memory, so arbitrarily long inputs are parsed in constant space.
+type Scanner struct {
+	// Lenient skips or repairs malformed cues instead of failing, recording
+	// each problem as a Diagnostic.
+	Lenient bool
+
+	// PreserveWhitespace keeps leading and interior whitespace in cue text,
+	// such as spaces used for alignment. Only trailing whitespace is removed.
+	PreserveWhitespace bool
+
+	lines       lineReader
+	timing      cueTiming
+	diagnostics []Diagnostic
+}
+
+// NewScanner creates a Scanner reading SBV content from reader.
+func NewScanner(reader io.Reader) *Scanner {
+	return &Scanner{
+		lines:  newLineReader(reader),
+		timing: NewConverter().sbvTiming(),
+	}
+}
+
+// Next returns the next SBV cue, or io.EOF when the input is exhausted.
+func (s *Scanner) Next() (Subtitle, error) {
+	s.lines.preserve = s.PreserveWhitespace
+	return nextCue(&s.lines, s.Lenient, &s.diagnostics, s.timing)
+}
+
+// Diagnostics returns the problems found so far in lenient mode.
+func (s *Scanner) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// SRTScanner reads SRT cues one at a time, in constant memory.
+type SRTScanner struct {
+	// Lenient skips or repairs malformed cues instead of failing, recording
+	// each problem as a Diagnostic.
+	Lenient bool
+
+	// PreserveWhitespace keeps leading and interior whitespace in cue text,
+	// such as spaces used for alignment. Only trailing whitespace is removed.
+	PreserveWhitespace bool
+
+	lines       lineReader
+	timing      cueTiming
+	diagnostics []Diagnostic
+}
+
+// NewSRTScanner creates an SRTScanner reading SRT content from reader.
+func NewSRTScanner(reader io.Reader) *SRTScanner {
+	return &SRTScanner{
+		lines:  newLineReader(reader),
+		timing: NewConverter().srtTiming(),
+	}
+}
+
+// Next returns the next SRT cue, or io.EOF when the input is exhausted.
+// Sequence numbers are discarded.
+func (s *SRTScanner) Next() (Subtitle, error) {
+	s.lines.preserve = s.PreserveWhitespace
+	return nextCue(&s.lines, s.Lenient, &s.diagnostics, s.timing)
+}
+
+// Diagnostics returns the problems found so far in lenient mode.
+func (s *SRTScanner) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// cueTiming describes the timing lines of a line-based subtitle format.
+type cueTiming struct {
+	// isTiming reports whether a line is a cue timing line.
+	isTiming func(line string) bool
+
+	// isFiller reports whether a non-timing line outside a cue is expected,
+	// such as an SRT sequence number. It may be nil.
+	isFiller func(line string) bool
+
+	// parse extracts the start and end times from a timing line.
+	parse func(line string) (time.Duration, time.Duration, error)
+
+	// column returns the 1-based column of the first malformed time in a
+	// timing line that parse rejected.
+	column func(line string) int
+}
+
+// nextCue reads the next cue with the given timing syntax. In lenient mode
+// malformed cues are skipped or repaired and recorded in diagnostics.
+func nextCue(lines *lineReader, lenient bool, diagnostics *[]Diagnostic, syntax cueTiming) (Subtitle, error) {
+	for {
+		line, ok := lines.next()
+		if !ok {
+			return Subtitle{}, lines.end()
+		}
+
+		// Skip empty lines and anything before a timing line
+		if line == "" {
+			continue
+		}
+		if !syntax.isTiming(line) {
+			if lenient && (syntax.isFiller == nil || !syntax.isFiller(line)) {
+				*diagnostics = append(*diagnostics, Diagnostic{
+					Line:     lines.line,
+					Severity: SeverityWarning,
+					Text:     line,
+					Message:  "text outside of a cue",
+					Action:   "ignored line",
+				})
+			}
+			continue
+		}
+
+		lineNumber := lines.line
+		startTime, endTime, err := syntax.parse(line)
+		if err != nil {
+			if !lenient {
+				return Subtitle{}, &ParseError{
+					Line:   lineNumber,
+					Column: syntax.column(line),
+					Raw:    line,
+					Err:    err,
+				}
+			}
+			lines.readText()
+			*diagnostics = append(*diagnostics, Diagnostic{
+				Line:     lineNumber,
+				Column:   syntax.column(line),
+				Severity: SeverityError,
+				Text:     line,
+				Message:  err.Error(),
+				Action:   "skipped cue",
+				Err:      err,
+			})
+			continue
+		}
+
+		text := lines.readText()
+		if lenient {
+			if endTime < startTime {
+				startTime, endTime = endTime, startTime
+				*diagnostics = append(*diagnostics, Diagnostic{
+					Line:     lineNumber,
+					Severity: SeverityWarning,
+					Text:     line,
+					Message:  "end time is before start time",
+					Action:   "swapped start and end times",
+					Err:      ErrEndBeforeStart,
+				})
+			}
+			if strings.TrimSpace(text) == "" {
+				*diagnostics = append(*diagnostics, Diagnostic{
+					Line:     lineNumber,
+					Severity: SeverityWarning,
+					Text:     line,
+					Message:  "cue has no text",
+					Action:   "kept empty cue",
+				})
+			}
+		}
+
+		return Subtitle{
+			StartTime: startTime,
+			EndTime:   endTime,
+			Text:      text,
+		}, nil
+	}
+}
+
+// SRTWriter writes SRT cues to an io.Writer as they arrive.
+type SRTWriter struct {
+	writer    io.Writer
+	converter *DefaultConverter
+	buf       bytes.Buffer
+	index     int
+}
+
+// NewSRTWriter creates an SRTWriter writing to writer.
+func NewSRTWriter(writer io.Writer) *SRTWriter {
+	return newSRTWriter(writer, NewConverter())
+}
+
+// newSRTWriter creates an SRTWriter formatting cues with converter's settings.
+func newSRTWriter(writer io.Writer, converter *DefaultConverter) *SRTWriter {
+	return &SRTWriter{writer: writer, converter: converter, index: converter.startIndex - 1}
+}
+
+// WriteCue writes a single SRT cue, numbering cues from 1 by default.
+func (w *SRTWriter) WriteCue(subtitle Subtitle) error {
+	w.index++
+	w.buf.Reset()
+	w.converter.writeSRTCue(&w.buf, w.index, subtitle)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write SRT content: %w", err)
+	}
+	return nil
+}
+
+// Flush is a no-op; SRT has no header or trailer.
+func (w *SRTWriter) Flush() error {
+	return nil
+}
+
+// SBVWriter writes SBV cues to an io.Writer as they arrive.
+type SBVWriter struct {
+	writer    io.Writer
+	converter *DefaultConverter
+	buf       bytes.Buffer
+	count     int
+}
+
+// NewSBVWriter creates an SBVWriter writing to writer.
+func NewSBVWriter(writer io.Writer) *SBVWriter {
+	return newSBVWriter(writer, NewConverter())
+}
+
+// newSBVWriter creates an SBVWriter formatting cues with converter's settings.
+func newSBVWriter(writer io.Writer, converter *DefaultConverter) *SBVWriter {
+	return &SBVWriter{writer: writer, converter: converter}
+}
+
+// WriteCue writes a single SBV cue, separated from the previous one by a blank line.
+func (w *SBVWriter) WriteCue(subtitle Subtitle) error {
+	w.buf.Reset()
+	w.converter.writeSBVCue(&w.buf, w.count > 0, subtitle)
+	w.count++
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write SBV content: %w", err)
+	}
+	return nil
+}
+
+// Flush is a no-op; SBV has no header or trailer.
+func (w *SBVWriter) Flush() error {
+	return nil
+}
+
+// VTTWriter writes WebVTT cues to an io.Writer as they arrive.
+type VTTWriter struct {
+	writer        io.Writer
+	converter     *DefaultConverter
+	opts          VTTOptions
+	buf           bytes.Buffer
+	index         int
+	headerWritten bool
+}
+
+// NewVTTWriter creates a VTTWriter writing to writer with the given cue options.
+func NewVTTWriter(writer io.Writer, opts VTTOptions) *VTTWriter {
+	return newVTTWriter(writer, NewConverter(), opts)
+}
+
+// newVTTWriter creates a VTTWriter formatting cues with converter's settings.
+func newVTTWriter(writer io.Writer, converter *DefaultConverter, opts VTTOptions) *VTTWriter {
+	return &VTTWriter{writer: writer, converter: converter, opts: opts, index: converter.startIndex - 1}
+}
+
+// WriteCue writes a single WebVTT cue, preceded by the file header for the first cue.
+func (w *VTTWriter) WriteCue(subtitle Subtitle) error {
+	w.index++
+	w.buf.Reset()
+	if !w.headerWritten {
+		w.buf.WriteString(vttHeader)
+	}
+	w.converter.writeVTTCue(&w.buf, w.index, subtitle, w.opts)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write VTT content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// Flush writes the WebVTT header if no cue has been written yet.
+func (w *VTTWriter) Flush() error {
+	if w.headerWritten {
+		return nil
+	}
+	if _, err := io.WriteString(w.writer, vttHeader); err != nil {
+		return fmt.Errorf("failed to write VTT content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// ASSWriter writes Advanced SubStation Alpha Dialogue lines to an io.Writer
+// as they arrive.
+type ASSWriter struct {
+	writer        io.Writer
+	opts          ASSOptions
+	buf           bytes.Buffer
+	headerWritten bool
+}
+
+// NewASSWriter creates an ASSWriter writing to writer with the given script options.
+func NewASSWriter(writer io.Writer, opts ASSOptions) *ASSWriter {
+	return &ASSWriter{writer: writer, opts: opts.withDefaults()}
+}
+
+// WriteCue writes a single Dialogue line, preceded by the script header for the first cue.
+func (w *ASSWriter) WriteCue(subtitle Subtitle) error {
+	w.buf.Reset()
+	if !w.headerWritten {
+		writeASSHeader(&w.buf, w.opts)
+	}
+	writeASSCue(&w.buf, subtitle, w.opts)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ASS content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// Flush writes the script header if no cue has been written yet.
+func (w *ASSWriter) Flush() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.buf.Reset()
+	writeASSHeader(&w.buf, w.opts)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write ASS content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// TTMLWriter writes TTML p elements to an io.Writer as they arrive.
+type TTMLWriter struct {
+	writer        io.Writer
+	opts          TTMLOptions
+	buf           bytes.Buffer
+	headerWritten bool
+}
+
+// NewTTMLWriter creates a TTMLWriter writing to writer with the given document options.
+func NewTTMLWriter(writer io.Writer, opts TTMLOptions) *TTMLWriter {
+	return &TTMLWriter{writer: writer, opts: opts.withDefaults()}
+}
+
+// WriteCue writes a single p element, preceded by the document header for the first cue.
+func (w *TTMLWriter) WriteCue(subtitle Subtitle) error {
+	w.buf.Reset()
+	if !w.headerWritten {
+		writeTTMLHeader(&w.buf, w.opts)
+	}
+	writeTTMLCue(&w.buf, subtitle)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write TTML content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// Flush closes the document, writing its header first if no cue has been
+// written yet. Cues written after Flush would fall outside the document.
+func (w *TTMLWriter) Flush() error {
+	w.buf.Reset()
+	if !w.headerWritten {
+		writeTTMLHeader(&w.buf, w.opts)
+	}
+	w.buf.WriteString(ttmlFooter)
+	if _, err := w.writer.Write(w.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write TTML content: %w", err)
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// ReadAll reads every remaining cue from reader.
+func ReadAll(reader CueReader) ([]Subtitle, error) {
+	var subtitles []Subtitle
+	for {
+		subtitle, err := reader.Next()
+		if err == io.EOF {
+			return subtitles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		subtitles = append(subtitles, subtitle)
+	}
+}
+
+// Copy streams every cue from src to dst and flushes dst. Each cue is written
+// as soon as it is read, so memory use does not grow with the input size.
+// It returns the number of cues copied.
+func Copy(dst CueWriter, src CueReader) (int, error) {
+	count := 0
+	for {
+		subtitle, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		if err := dst.WriteCue(subtitle); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, dst.Flush()
+}
@@ -0,0 +1,242 @@
+package sbv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScannerNext(t *testing.T) {
+	sbvContent := `0:00:01.000,0:00:04.000
+First subtitle
+spanning two lines
+
+0:00:05.500,0:00:08.200
+Second subtitle`
+
+	scanner := NewScanner(strings.NewReader(sbvContent))
+
+	first, err := scanner.Next()
+	if err != nil {
+		t.Fatalf("Next() unexpected error: %v", err)
+	}
+	if first.StartTime != 1*time.Second || first.Text != "First subtitle\nspanning two lines" {
+		t.Errorf("Next() first = %+v", first)
+	}
+
+	second, err := scanner.Next()
+	if err != nil {
+		t.Fatalf("Next() unexpected error: %v", err)
+	}
+	if second.EndTime != 8*time.Second+200*time.Millisecond || second.Text != "Second subtitle" {
+		t.Errorf("Next() second = %+v", second)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := scanner.Next(); err != io.EOF {
+			t.Errorf("Next() after last cue error = %v, want io.EOF", err)
+		}
+	}
+}
+
+func TestScannerNextError(t *testing.T) {
+	scanner := NewScanner(strings.NewReader("0:00:01.000,0:00:04.000\nOK\n\n0:99:01.000,0:00:04.000\nBad\n"))
+
+	if _, err := scanner.Next(); err != nil {
+		t.Fatalf("Next() unexpected error: %v", err)
+	}
+	_, err := scanner.Next()
+	if err == nil || err == io.EOF {
+		t.Fatalf("Next() error = %v, want parse error", err)
+	}
+	if !strings.Contains(err.Error(), "failed to parse subtitle block") {
+		t.Errorf("Next() error = %v, want subtitle block error", err)
+	}
+}
+
+func TestScannerReadError(t *testing.T) {
+	readErr := errors.New("disk on fire")
+	scanner := NewScanner(io.MultiReader(
+		strings.NewReader("0:00:01.000,0:00:04.000\nOK\n\n"),
+		&failingReader{err: readErr},
+	))
+
+	if _, err := scanner.Next(); err != nil {
+		t.Fatalf("Next() unexpected error: %v", err)
+	}
+	if _, err := scanner.Next(); !errors.Is(err, readErr) {
+		t.Errorf("Next() error = %v, want %v", err, readErr)
+	}
+}
+
+func TestSRTScannerNext(t *testing.T) {
+	scanner := NewSRTScanner(strings.NewReader("1\n00:00:01,000 --> 00:00:02,000\nOne\n\n2\n00:00:03,000 --> 00:00:04,000\nTwo\n"))
+
+	subtitles, err := ReadAll(scanner)
+	if err != nil {
+		t.Fatalf("ReadAll() unexpected error: %v", err)
+	}
+	if len(subtitles) != 2 || subtitles[1].Text != "Two" || subtitles[1].StartTime != 3*time.Second {
+		t.Errorf("ReadAll() = %+v", subtitles)
+	}
+}
+
+func TestCueWriters(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 2 * time.Second, Text: "One"},
+		{StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "Two\nlines"},
+	}
+	converter := NewConverter()
+
+	tests := []struct {
+		name   string
+		writer func(w io.Writer) CueWriter
+		want   string
+	}{
+		{
+			name:   "srt",
+			writer: func(w io.Writer) CueWriter { return NewSRTWriter(w) },
+			want:   converter.ConvertToSRT(subtitles),
+		},
+		{
+			name:   "sbv",
+			writer: func(w io.Writer) CueWriter { return NewSBVWriter(w) },
+			want:   converter.ConvertToSBV(subtitles),
+		},
+		{
+			name: "vtt",
+			writer: func(w io.Writer) CueWriter {
+				return NewVTTWriter(w, VTTOptions{CueIdentifiers: true})
+			},
+			want: converter.ConvertToVTT(subtitles, VTTOptions{CueIdentifiers: true}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCues(tt.writer(&buf), subtitles); err != nil {
+				t.Fatalf("writeCues() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("streamed output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestVTTWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewVTTWriter(&buf, VTTOptions{})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if buf.String() != "WEBVTT\n\n" {
+		t.Errorf("Flush() wrote %q, want header once", buf.String())
+	}
+}
+
+func TestCueWriterError(t *testing.T) {
+	writeErr := errors.New("pipe closed")
+	writer := NewSRTWriter(&failingWriter{err: writeErr})
+
+	err := writer.WriteCue(Subtitle{EndTime: time.Second, Text: "Hi"})
+	if !errors.Is(err, writeErr) {
+		t.Errorf("WriteCue() error = %v, want %v", err, writeErr)
+	}
+}
+
+func TestCopyStreamsEachCue(t *testing.T) {
+	const cues = 10000
+
+	// Generate SBV lazily so the input never exists in memory as a whole
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < cues; i++ {
+			start := time.Duration(i) * time.Second
+			fmt.Fprintf(pw, "%s,%s\nCue %d\n\n",
+				NewConverter().formatSBVTime(start),
+				NewConverter().formatSBVTime(start+500*time.Millisecond), i)
+		}
+		pw.Close()
+	}()
+
+	counter := &countingWriter{}
+	recorder := &recordingCueWriter{next: NewSRTWriter(counter), counter: counter}
+
+	n, err := Copy(recorder, NewScanner(pr))
+	if err != nil {
+		t.Fatalf("Copy() unexpected error: %v", err)
+	}
+	if n != cues {
+		t.Errorf("Copy() copied %d cues, want %d", n, cues)
+	}
+	if recorder.unflushed != 0 {
+		t.Errorf("Copy() left %d cues unwritten after WriteCue returned", recorder.unflushed)
+	}
+	if !recorder.flushed {
+		t.Errorf("Copy() did not flush the writer")
+	}
+}
+
+// failingReader always fails with err.
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// failingWriter always fails with err.
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+// countingWriter counts the bytes and writes it receives.
+type countingWriter struct {
+	bytes  int
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.bytes += len(p)
+	w.writes++
+	return len(p), nil
+}
+
+// recordingCueWriter checks that every cue reaches the underlying writer
+// before WriteCue returns.
+type recordingCueWriter struct {
+	next      CueWriter
+	counter   *countingWriter
+	unflushed int
+	flushed   bool
+}
+
+func (w *recordingCueWriter) WriteCue(subtitle Subtitle) error {
+	before := w.counter.writes
+	if err := w.next.WriteCue(subtitle); err != nil {
+		return err
+	}
+	if w.counter.writes == before {
+		w.unflushed++
+	}
+	return nil
+}
+
+func (w *recordingCueWriter) Flush() error {
+	w.flushed = true
+	return w.next.Flush()
+}
@@ -0,0 +1,98 @@
+package sbv
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimeMapping maps a cue time to its new value.
+type TimeMapping func(t time.Duration) time.Duration
+
+// SyncPoint pins a time in the original subtitles to the time it should have
+// after resyncing.
+type SyncPoint struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseTimestamp parses a clock timestamp in SBV (H:MM:SS.mmm) or SRT
+// (HH:MM:SS,mmm) notation.
+func ParseTimestamp(s string) (time.Duration, error) {
+	return NewConverter().parseSRTTime(s)
+}
+
+// Retime returns a copy of subtitles with every start and end time passed
+// through mapping. Times that would become negative are clamped to zero.
+func Retime(subtitles []Subtitle, mapping TimeMapping) []Subtitle {
+	retimed := make([]Subtitle, len(subtitles))
+	for i, subtitle := range subtitles {
+		subtitle.StartTime = max(mapping(subtitle.StartTime), 0)
+		subtitle.EndTime = max(mapping(subtitle.EndTime), 0)
+		retimed[i] = subtitle
+	}
+	return retimed
+}
+
+// Shift returns a copy of subtitles with every cue moved by offset, which may
+// be negative. Times before zero are clamped to zero.
+func Shift(subtitles []Subtitle, offset time.Duration) []Subtitle {
+	return Retime(subtitles, func(t time.Duration) time.Duration {
+		return t + offset
+	})
+}
+
+// Scale returns a copy of subtitles with every time multiplied by factor,
+// stretching or compressing the timeline around zero.
+func Scale(subtitles []Subtitle, factor float64) ([]Subtitle, error) {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return nil, fmt.Errorf("scale factor must be a positive number: %v", factor)
+	}
+	return Retime(subtitles, LinearMapping(factor, 0)), nil
+}
+
+// Resync returns a copy of subtitles retimed by the linear mapping that moves
+// a.From to a.To and b.From to b.To, correcting both an offset and a steady
+// drift. Times before zero are clamped to zero.
+func Resync(subtitles []Subtitle, a, b SyncPoint) ([]Subtitle, error) {
+	if a.From == b.From {
+		return nil, fmt.Errorf("sync points must have different source times")
+	}
+
+	factor := float64(b.To-a.To) / float64(b.From-a.From)
+	if factor <= 0 {
+		return nil, fmt.Errorf("sync points must keep their order")
+	}
+
+	offset := a.To - scaleDuration(a.From, factor)
+	return Retime(subtitles, LinearMapping(factor, offset)), nil
+}
+
+// CuePoint returns a SyncPoint that moves the start of the cue at index,
+// counted from zero, to the given time.
+func CuePoint(subtitles []Subtitle, index int, to time.Duration) (SyncPoint, error) {
+	if index < 0 || index >= len(subtitles) {
+		return SyncPoint{}, fmt.Errorf("cue %d out of range (1-%d)", index+1, len(subtitles))
+	}
+	return SyncPoint{From: subtitles[index].StartTime, To: to}, nil
+}
+
+// LinearMapping returns a TimeMapping computing t*factor + offset.
+func LinearMapping(factor float64, offset time.Duration) TimeMapping {
+	return func(t time.Duration) time.Duration {
+		return scaleDuration(t, factor) + offset
+	}
+}
+
+// scaleDuration multiplies d by factor, rounding to the nearest nanosecond
+// and saturating instead of overflowing.
+func scaleDuration(d time.Duration, factor float64) time.Duration {
+	scaled := math.Round(float64(d) * factor)
+	if scaled >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if scaled <= math.MinInt64 {
+		return math.MinInt64
+	}
+	return time.Duration(scaled)
+}
@@ -0,0 +1,184 @@
+package sbv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func timingFixture() []Subtitle {
+	return []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 3 * time.Second, Text: "First"},
+		{StartTime: 10 * time.Second, EndTime: 12 * time.Second, Text: "Second"},
+		{StartTime: 100 * time.Second, EndTime: 104 * time.Second, Text: "Third"},
+	}
+}
+
+// cueTimes returns the start and end time of every cue.
+func cueTimes(subtitles []Subtitle) [][2]time.Duration {
+	times := make([][2]time.Duration, len(subtitles))
+	for i, subtitle := range subtitles {
+		times[i] = [2]time.Duration{subtitle.StartTime, subtitle.EndTime}
+	}
+	return times
+}
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   [][2]time.Duration
+	}{
+		{
+			name:   "forward",
+			offset: 1500 * time.Millisecond,
+			want: [][2]time.Duration{
+				{2500 * time.Millisecond, 4500 * time.Millisecond},
+				{11500 * time.Millisecond, 13500 * time.Millisecond},
+				{101500 * time.Millisecond, 105500 * time.Millisecond},
+			},
+		},
+		{
+			name:   "backward clamps at zero",
+			offset: -2 * time.Second,
+			want: [][2]time.Duration{
+				{0, 1 * time.Second},
+				{8 * time.Second, 10 * time.Second},
+				{98 * time.Second, 102 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtitles := timingFixture()
+			got := Shift(subtitles, tt.offset)
+			if !reflect.DeepEqual(cueTimes(got), tt.want) {
+				t.Errorf("Shift() = %v, want %v", cueTimes(got), tt.want)
+			}
+			if !reflect.DeepEqual(subtitles, timingFixture()) {
+				t.Errorf("Shift() modified its input")
+			}
+			if got[2].Text != "Third" {
+				t.Errorf("Shift() text = %q, want %q", got[2].Text, "Third")
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	got, err := Scale(timingFixture(), 25.0/24.0)
+	if err != nil {
+		t.Fatalf("Scale() unexpected error: %v", err)
+	}
+	want := [][2]time.Duration{
+		{1041666667, 3125 * time.Millisecond},
+		{10416666667, 12500 * time.Millisecond},
+		{104166666667, 108333333333},
+	}
+	if !reflect.DeepEqual(cueTimes(got), want) {
+		t.Errorf("Scale() = %v, want %v", cueTimes(got), want)
+	}
+
+	for _, factor := range []float64{0, -1} {
+		if _, err := Scale(timingFixture(), factor); err == nil {
+			t.Errorf("Scale(%v) expected error, got nil", factor)
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    SyncPoint
+		want    [][2]time.Duration
+		wantErr bool
+	}{
+		{
+			name: "offset only",
+			a:    SyncPoint{From: 1 * time.Second, To: 2 * time.Second},
+			b:    SyncPoint{From: 100 * time.Second, To: 101 * time.Second},
+			want: [][2]time.Duration{
+				{2 * time.Second, 4 * time.Second},
+				{11 * time.Second, 13 * time.Second},
+				{101 * time.Second, 105 * time.Second},
+			},
+		},
+		{
+			name: "offset and drift",
+			a:    SyncPoint{From: 10 * time.Second, To: 10 * time.Second},
+			b:    SyncPoint{From: 100 * time.Second, To: 190 * time.Second},
+			want: [][2]time.Duration{
+				{0, 0},
+				{10 * time.Second, 14 * time.Second},
+				{190 * time.Second, 198 * time.Second},
+			},
+		},
+		{
+			name:    "same source time",
+			a:       SyncPoint{From: 1 * time.Second, To: 2 * time.Second},
+			b:       SyncPoint{From: 1 * time.Second, To: 3 * time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "reversed order",
+			a:       SyncPoint{From: 1 * time.Second, To: 10 * time.Second},
+			b:       SyncPoint{From: 100 * time.Second, To: 5 * time.Second},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resync(timingFixture(), tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cueTimes(got), tt.want) {
+				t.Errorf("Resync() = %v, want %v", cueTimes(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCuePoint(t *testing.T) {
+	subtitles := timingFixture()
+
+	point, err := CuePoint(subtitles, 1, 20*time.Second)
+	if err != nil {
+		t.Fatalf("CuePoint() unexpected error: %v", err)
+	}
+	if point != (SyncPoint{From: 10 * time.Second, To: 20 * time.Second}) {
+		t.Errorf("CuePoint() = %+v", point)
+	}
+
+	if _, err := CuePoint(subtitles, 3, 0); err == nil {
+		t.Errorf("CuePoint() expected error for index out of range, got nil")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "0:01:02.500", want: 62500 * time.Millisecond},
+		{input: "01:01:02,500", want: time.Hour + 62500*time.Millisecond},
+		{input: "1:02", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimestamp(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
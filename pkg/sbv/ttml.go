@@ -0,0 +1,697 @@
+package sbv
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TTMLOptions controls the document written by the TTML encoder.
+type TTMLOptions struct {
+	// Language is the BCP 47 language tag of the captions, written as the
+	// xml:lang attribute of the document. Empty uses "en".
+	Language string
+
+	// Title is written to the document metadata when set.
+	Title string
+
+	// Encoding is the character encoding the document is stored in, named
+	// in its XML declaration. The encoder itself always writes UTF-8; wrap
+	// the writer in a TextWriter for the same encoding. EncodingAuto
+	// declares UTF-8.
+	Encoding Encoding
+}
+
+// ttmlProfile designates the IMSC1 text profile the documents conform to.
+const ttmlProfile = "http://www.w3.org/ns/ttml/profile/imsc1/text"
+
+// ttmlFooter closes the elements opened by the document header.
+const ttmlFooter = "    </div>\n  </body>\n</tt>\n"
+
+// ttmlSpanStyles maps basic markup tags to the styling attributes of the span
+// that replaces them.
+var ttmlSpanStyles = map[string]string{
+	"i": `tts:fontStyle="italic"`,
+	"b": `tts:fontWeight="bold"`,
+	"u": `tts:textDecoration="underline"`,
+	"s": `tts:textDecoration="lineThrough"`,
+}
+
+// ttmlEscaper escapes the characters that cannot appear as-is in XML content
+// and attribute values.
+var ttmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// withDefaults fills in the language of options that leave it out.
+func (o TTMLOptions) withDefaults() TTMLOptions {
+	if o.Language == "" {
+		o.Language = "en"
+	}
+	return o
+}
+
+// ttmlEncodingName returns the name of encoding in an XML declaration. Both
+// UTF-16 byte orders are declared as UTF-16, which TextWriter marks with a
+// byte order mark.
+func ttmlEncodingName(encoding Encoding) string {
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return "UTF-16"
+	case EncodingWindows1252:
+		return "windows-1252"
+	default:
+		return "UTF-8"
+	}
+}
+
+// writeTTMLHeader formats the XML declaration, the tt element with its head
+// and the opening of the body. Cues are shown in a bottom region in white
+// sans-serif text on a black background.
+func writeTTMLHeader(buf cueBuffer, opts TTMLOptions) {
+	buf.WriteString(`<?xml version="1.0" encoding="` + ttmlEncodingName(opts.Encoding) + `"?>` + "\n")
+	buf.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml"`)
+	buf.WriteString(` xmlns:tts="http://www.w3.org/ns/ttml#styling"`)
+	buf.WriteString(` xmlns:ttm="http://www.w3.org/ns/ttml#metadata"`)
+	buf.WriteString(` xmlns:ttp="http://www.w3.org/ns/ttml#parameter"`)
+	buf.WriteString(` ttp:timeBase="media" ttp:profile="` + ttmlProfile + `"`)
+	buf.WriteString(` xml:lang="` + ttmlEscaper.Replace(opts.Language) + `">` + "\n")
+
+	buf.WriteString("  <head>\n")
+	if opts.Title != "" {
+		buf.WriteString("    <metadata>\n")
+		buf.WriteString("      <ttm:title>" + ttmlEscaper.Replace(opts.Title) + "</ttm:title>\n")
+		buf.WriteString("    </metadata>\n")
+	}
+	buf.WriteString("    <styling>\n")
+	buf.WriteString(`      <style xml:id="default" tts:fontFamily="proportionalSansSerif" tts:fontSize="100%" tts:lineHeight="125%"`)
+	buf.WriteString(` tts:color="white" tts:backgroundColor="black" tts:textAlign="center"/>` + "\n")
+	buf.WriteString("    </styling>\n")
+	buf.WriteString("    <layout>\n")
+	buf.WriteString(`      <region xml:id="bottom" tts:origin="10% 10%" tts:extent="80% 80%" tts:displayAlign="after"/>` + "\n")
+	buf.WriteString("    </layout>\n")
+	buf.WriteString("  </head>\n")
+
+	buf.WriteString(`  <body region="bottom" style="default">` + "\n")
+	buf.WriteString("    <div>\n")
+}
+
+// writeTTMLCue formats a single p element.
+func writeTTMLCue(buf cueBuffer, subtitle Subtitle) {
+	buf.WriteString(`      <p begin="` + formatTTMLTime(subtitle.StartTime))
+	buf.WriteString(`" end="` + formatTTMLTime(subtitle.EndTime) + `">`)
+	buf.WriteString(ttmlText(subtitle.Text))
+	buf.WriteString("</p>\n")
+}
+
+// formatTTMLTime formats a time.Duration as a TTML clock time (HH:MM:SS.mmm),
+// the same as a WebVTT timestamp.
+func formatTTMLTime(duration time.Duration) string {
+	hours, minutes, seconds, milliseconds := splitDuration(duration)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds)
+}
+
+// ttmlText converts cue text to the content of a p element: special
+// characters are escaped, line breaks become <br/> and basic markup such as
+// <i> becomes a styled span. Tags left open are closed at the end of the cue
+// and stray closing tags are dropped, so the result is always well-formed.
+func ttmlText(text string) string {
+	var (
+		result strings.Builder
+		open   []string // markup tags currently open, innermost last
+	)
+
+	writeText := func(text string) {
+		for i, line := range strings.Split(text, "\n") {
+			if i > 0 {
+				result.WriteString("<br/>")
+			}
+			result.WriteString(ttmlEscaper.Replace(line))
+		}
+	}
+	openSpan := func(tag string) {
+		result.WriteString("<span " + ttmlSpanStyles[tag] + ">")
+	}
+
+	last := 0
+	for _, match := range htmlStylePattern.FindAllStringSubmatchIndex(text, -1) {
+		writeText(text[last:match[0]])
+		last = match[1]
+
+		closing := match[3] > match[2]
+		tag := strings.ToLower(text[match[4]:match[5]])
+		i := slices.Index(open, tag)
+		switch {
+		case !closing && i < 0:
+			openSpan(tag)
+			open = append(open, tag)
+		case closing && i >= 0:
+			// Close the spans opened inside it too, then reopen them
+			for range open[i:] {
+				result.WriteString("</span>")
+			}
+			open = slices.Delete(open, i, i+1)
+			for _, reopened := range open[i:] {
+				openSpan(reopened)
+			}
+		}
+	}
+	writeText(text[last:])
+	for range open {
+		result.WriteString("</span>")
+	}
+
+	return result.String()
+}
+
+// TTMLScanner reads the p elements of a TTML or DFXP document one at a time.
+// Times may be clock times ("00:00:01.500", or "00:00:01:12" with frames) or
+// offset times ("1.5s", "36f", "15000000t"), and begin and end are offset by
+// the begin of every enclosing body, div and p. Text in nested span elements
+// is kept, with italic, bold, underline and line-through styling turned into
+// <i>, <b>, <u> and <s> markup; br elements become line breaks.
+//
+// A p element without an end or dur ends with the closest enclosing element
+// that has one. If none has, it lasts until the next p element begins, or
+// for the last one until the latest end time in the document.
+type TTMLScanner struct {
+	// Lenient skips p elements with malformed timing and ignores malformed
+	// document parameters instead of failing, recording each problem as a
+	// Diagnostic.
+	Lenient bool
+
+	decoder     *xml.Decoder
+	timing      ttmlTiming
+	styles      map[string][]string
+	elements    []ttmlElement
+	diagnostics []Diagnostic
+
+	// open is the cue of an open-ended p element, waiting for the next p
+	// element to begin. done is set once its end time is known.
+	open      *Subtitle
+	done      bool
+	latestEnd time.Duration
+}
+
+// ttmlTiming holds the document parameters that time expressions depend on.
+type ttmlTiming struct {
+	frameRate    float64 // effective frames per second
+	subFrameRate float64
+	tickRate     float64
+}
+
+// ttmlElement is an open element of the document body.
+type ttmlElement struct {
+	name      string
+	begin     time.Duration
+	end       time.Duration
+	hasEnd    bool
+	markup    []string // markup tags opened by the element's styling
+	skipped   bool     // inside a p element skipped in lenient mode
+	paragraph *ttmlParagraph
+}
+
+// ttmlParagraph collects the text of the p element being read.
+type ttmlParagraph struct {
+	line int
+	raw  string
+	text strings.Builder
+}
+
+// ttmlSpaceReplacer turns the XML whitespace characters other than the space
+// into spaces.
+var ttmlSpaceReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// ttmlClockPattern matches clock times, with a fraction of a second or with
+// frames and sub-frames.
+var ttmlClockPattern = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})(?:(\.\d+)|:(\d{2,})(?:\.(\d+))?)?$`)
+
+// ttmlOffsetPattern matches offset times: a number followed by a metric.
+var ttmlOffsetPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|m|s|ms|f|t)$`)
+
+// NewTTMLScanner creates a TTMLScanner reading a TTML document from reader.
+func NewTTMLScanner(reader io.Reader) *TTMLScanner {
+	decoder := xml.NewDecoder(autoTextReader(reader))
+	// The TextReader has already decoded the input to UTF-8, whatever the
+	// XML declaration says
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return &TTMLScanner{
+		decoder: decoder,
+		timing:  ttmlTiming{frameRate: 30, subFrameRate: 1, tickRate: 1},
+		styles:  map[string][]string{},
+	}
+}
+
+// Next returns the cue of the next p element, or io.EOF when the input is exhausted.
+func (s *TTMLScanner) Next() (Subtitle, error) {
+	for {
+		token, err := s.decoder.Token()
+		if err == io.EOF {
+			if s.open != nil {
+				s.open.EndTime = max(s.latestEnd, s.open.StartTime)
+				return s.takeOpen(), nil
+			}
+			return Subtitle{}, io.EOF
+		}
+		if err != nil {
+			line, column := s.decoder.InputPos()
+			return Subtitle{}, &ParseError{Line: line, Column: column, Err: fmt.Errorf("invalid TTML document: %w", err)}
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if err := s.startElement(token); err != nil {
+				if !s.Lenient {
+					return Subtitle{}, err
+				}
+				s.skip(token, err.(*ParseError))
+			}
+			if s.done {
+				return s.takeOpen(), nil
+			}
+		case xml.EndElement:
+			if subtitle, ok := s.endElement(); ok {
+				return subtitle, nil
+			}
+		case xml.CharData:
+			if paragraph := s.paragraph(); paragraph != nil {
+				// Line breaks in the source are whitespace; only br breaks lines
+				paragraph.text.WriteString(ttmlSpaceReplacer.Replace(string(token)))
+			}
+		}
+	}
+}
+
+// Diagnostics returns the problems found so far in lenient mode.
+func (s *TTMLScanner) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// takeOpen returns the cue of the open-ended p element and forgets it.
+func (s *TTMLScanner) takeOpen() Subtitle {
+	subtitle := *s.open
+	s.open, s.done = nil, false
+	return subtitle
+}
+
+// startElement opens an element, working out its timing and styling. Only
+// the timing of p elements and their ancestors is checked.
+func (s *TTMLScanner) startElement(token xml.StartElement) error {
+	var parent ttmlElement
+	if len(s.elements) > 0 {
+		parent = s.elements[len(s.elements)-1]
+	}
+	element := ttmlElement{
+		name:   token.Name.Local,
+		begin:  parent.begin,
+		end:    parent.end,
+		hasEnd: parent.hasEnd,
+	}
+	if parent.skipped {
+		element.skipped = true
+		s.elements = append(s.elements, element)
+		return nil
+	}
+
+	paragraph := s.paragraph()
+	switch element.name {
+	case "tt":
+		if err := s.readParameters(token); err != nil {
+			return err
+		}
+	case "style":
+		if id := ttmlAttr(token, "id"); id != "" {
+			s.styles[id] = s.styleMarkup(token, nil)
+		}
+	case "body", "div", "p", "span":
+		if err := s.readTiming(token, &element); err != nil {
+			return err
+		}
+		if element.name == "p" {
+			if s.open != nil {
+				s.open.EndTime = max(element.begin, s.open.StartTime)
+				s.done = true
+			}
+			line, _ := s.decoder.InputPos()
+			paragraph = &ttmlParagraph{line: line, raw: ttmlStartTag(token)}
+			element.paragraph = paragraph
+		}
+		if paragraph != nil {
+			element.markup = s.styleMarkup(token, s.openMarkup())
+			for _, tag := range element.markup {
+				paragraph.text.WriteString("<" + tag + ">")
+			}
+		}
+	case "br":
+		if paragraph != nil {
+			paragraph.text.WriteByte('\n')
+		}
+	}
+
+	s.elements = append(s.elements, element)
+	return nil
+}
+
+// endElement closes the innermost element. It returns the cue of a p element
+// with any text.
+func (s *TTMLScanner) endElement() (Subtitle, bool) {
+	if len(s.elements) == 0 {
+		return Subtitle{}, false
+	}
+	element := s.elements[len(s.elements)-1]
+	s.elements = s.elements[:len(s.elements)-1]
+	if element.skipped {
+		return Subtitle{}, false
+	}
+
+	paragraph := element.paragraph
+	if paragraph == nil {
+		paragraph = s.paragraph()
+	}
+	if paragraph != nil {
+		for i := len(element.markup) - 1; i >= 0; i-- {
+			paragraph.text.WriteString("</" + element.markup[i] + ">")
+		}
+	}
+	if element.paragraph == nil {
+		return Subtitle{}, false
+	}
+
+	subtitle := Subtitle{StartTime: element.begin, EndTime: element.end, Text: ttmlCueText(paragraph.text.String())}
+	// Paragraphs with nothing but styling have no text to show
+	if strings.TrimSpace(htmlStylePattern.ReplaceAllString(subtitle.Text, "")) == "" {
+		return Subtitle{}, false
+	}
+	if !element.hasEnd {
+		// Shown until the next p element begins
+		s.open = &subtitle
+		return Subtitle{}, false
+	}
+	if subtitle.EndTime < subtitle.StartTime && s.Lenient {
+		subtitle.StartTime, subtitle.EndTime = subtitle.EndTime, subtitle.StartTime
+		s.diagnostics = append(s.diagnostics, Diagnostic{
+			Line:     paragraph.line,
+			Severity: SeverityWarning,
+			Text:     paragraph.raw,
+			Message:  "end time is before start time",
+			Action:   "swapped start and end times",
+			Err:      ErrEndBeforeStart,
+		})
+	}
+
+	return subtitle, true
+}
+
+// skip records err as a Diagnostic and ignores the element token that caused
+// it, together with its content.
+func (s *TTMLScanner) skip(token xml.StartElement, err *ParseError) {
+	action := "skipped cue"
+	if name := token.Name.Local; name != "p" && s.paragraph() == nil {
+		action = "skipped " + name + " element and the cues in it"
+	}
+
+	s.elements = append(s.elements, ttmlElement{skipped: true})
+	s.diagnostics = append(s.diagnostics, Diagnostic{
+		Line:     err.Line,
+		Column:   err.Column,
+		Severity: SeverityError,
+		Text:     err.Raw,
+		Message:  err.Err.Error(),
+		Action:   action,
+		Err:      err.Err,
+	})
+}
+
+// paragraph returns the p element being read, or nil outside of one.
+func (s *TTMLScanner) paragraph() *ttmlParagraph {
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		if s.elements[i].skipped {
+			return nil
+		}
+		if s.elements[i].paragraph != nil {
+			return s.elements[i].paragraph
+		}
+	}
+	return nil
+}
+
+// openMarkup returns the markup tags opened by the elements of the p element
+// being read.
+func (s *TTMLScanner) openMarkup() []string {
+	var open []string
+	for i := len(s.elements) - 1; i >= 0; i-- {
+		open = append(open, s.elements[i].markup...)
+		if s.elements[i].paragraph != nil {
+			break
+		}
+	}
+	return open
+}
+
+// readParameters reads the frame and tick rates that time expressions are
+// counted in from the tt element. In lenient mode a malformed parameter is
+// reported for the whole document and its default is used instead.
+func (s *TTMLScanner) readParameters(token xml.StartElement) error {
+	invalid := func(name, value string) error {
+		err := s.errorf(token, value, fmt.Errorf("invalid %s: %s", name, value)).(*ParseError)
+		if !s.Lenient {
+			return err
+		}
+		s.diagnostics = append(s.diagnostics, Diagnostic{
+			Line:     err.Line,
+			Column:   err.Column,
+			Severity: SeverityWarning,
+			Text:     err.Raw,
+			Message:  err.Err.Error(),
+			Action:   "used the default " + name + " for the document",
+			Err:      err.Err,
+		})
+		return nil
+	}
+	rate := func(name string) (float64, bool, error) {
+		value := ttmlAttr(token, name)
+		if value == "" {
+			return 0, false, nil
+		}
+		rate, err := strconv.Atoi(value)
+		if err != nil || rate <= 0 {
+			return 0, false, invalid(name, value)
+		}
+		return float64(rate), true, nil
+	}
+
+	frameRate, hasFrameRate, err := rate("frameRate")
+	if err != nil {
+		return err
+	}
+	if hasFrameRate {
+		s.timing.frameRate = frameRate
+	}
+	if multiplier := ttmlAttr(token, "frameRateMultiplier"); multiplier != "" {
+		var numerator, denominator int
+		if _, err := fmt.Sscanf(multiplier, "%d %d", &numerator, &denominator); err != nil || numerator <= 0 || denominator <= 0 {
+			if err := invalid("frameRateMultiplier", multiplier); err != nil {
+				return err
+			}
+		} else {
+			s.timing.frameRate *= float64(numerator) / float64(denominator)
+		}
+	}
+	subFrameRate, hasSubFrameRate, err := rate("subFrameRate")
+	if err != nil {
+		return err
+	}
+	if hasSubFrameRate {
+		s.timing.subFrameRate = subFrameRate
+	}
+
+	// Without a tick rate, ticks are sub-frames of the effective frame rate
+	// if a frame rate is given, or seconds
+	tickRate, hasTickRate, err := rate("tickRate")
+	switch {
+	case err != nil:
+		return err
+	case hasTickRate:
+		s.timing.tickRate = tickRate
+	case hasFrameRate:
+		s.timing.tickRate = s.timing.frameRate * s.timing.subFrameRate
+	}
+	return nil
+}
+
+// readTiming sets the begin and end of element from its begin, end and dur
+// attributes. Begin and end are offsets from the begin of the parent element.
+func (s *TTMLScanner) readTiming(token xml.StartElement, element *ttmlElement) error {
+	parentBegin := element.begin
+	parse := func(name string) (time.Duration, bool, error) {
+		value := ttmlAttr(token, name)
+		if value == "" {
+			return 0, false, nil
+		}
+		offset, err := s.timing.parse(value)
+		if err != nil {
+			return 0, false, s.errorf(token, value, fmt.Errorf("failed to parse %s time: %w", name, err))
+		}
+		return offset, true, nil
+	}
+
+	begin, _, err := parse("begin")
+	if err != nil {
+		return err
+	}
+	element.begin = parentBegin + begin
+
+	end, hasEnd, err := parse("end")
+	if err != nil {
+		return err
+	}
+	dur, hasDur, err := parse("dur")
+	if err != nil {
+		return err
+	}
+	switch {
+	case hasEnd:
+		element.end, element.hasEnd = parentBegin+end, true
+	case hasDur:
+		element.end, element.hasEnd = element.begin+dur, true
+	}
+	if element.hasEnd {
+		s.latestEnd = max(s.latestEnd, element.end)
+	}
+	return nil
+}
+
+// styleMarkup returns the markup tags for the italic, bold, underline and
+// line-through styling of an element or style, inline or through the styles
+// it references, leaving out those in open.
+func (s *TTMLScanner) styleMarkup(token xml.StartElement, open []string) []string {
+	var tags []string
+	add := func(tag string) {
+		if !slices.Contains(open, tag) && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, id := range strings.Fields(ttmlAttr(token, "style")) {
+		for _, tag := range s.styles[id] {
+			add(tag)
+		}
+	}
+	if style := ttmlAttr(token, "fontStyle"); style == "italic" || style == "oblique" {
+		add("i")
+	}
+	if ttmlAttr(token, "fontWeight") == "bold" {
+		add("b")
+	}
+	decoration := ttmlAttr(token, "textDecoration")
+	if slices.Contains(strings.Fields(decoration), "underline") {
+		add("u")
+	}
+	if slices.Contains(strings.Fields(decoration), "lineThrough") {
+		add("s")
+	}
+	return tags
+}
+
+// errorf returns a ParseError at the current position of the decoder for the
+// element token.
+func (s *TTMLScanner) errorf(token xml.StartElement, value string, err error) error {
+	line, column := s.decoder.InputPos()
+	raw := ttmlStartTag(token)
+	if value != "" {
+		raw = value
+	}
+	return &ParseError{Line: line, Column: column, Raw: raw, Err: err}
+}
+
+// parse parses a TTML time expression: a clock time ("01:02:03.500",
+// "01:02:03:12" with frames, "01:02:03:12.1" with sub-frames) or an offset
+// time ("1.5s", "90m", "500ms", "36f", "10000000t").
+func (t ttmlTiming) parse(expression string) (time.Duration, error) {
+	var seconds float64
+	if match := ttmlClockPattern.FindStringSubmatch(expression); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		secs, _ := strconv.Atoi(match[3])
+		if minutes > 59 || secs > 59 {
+			return 0, errorf(ErrOutOfRange, "minutes or seconds out of range (0-59): %s", expression)
+		}
+		seconds = float64(hours)*3600 + float64(minutes)*60 + float64(secs)
+		switch {
+		case match[4] != "":
+			fraction, _ := strconv.ParseFloat(match[4], 64)
+			seconds += fraction
+		case match[5] != "":
+			frames, _ := strconv.Atoi(match[5])
+			seconds += float64(frames) / t.frameRate
+			if match[6] != "" {
+				subFrames, _ := strconv.Atoi(match[6])
+				seconds += float64(subFrames) / (t.frameRate * t.subFrameRate)
+			}
+		}
+	} else if match := ttmlOffsetPattern.FindStringSubmatch(expression); match != nil {
+		value, _ := strconv.ParseFloat(match[1], 64)
+		switch match[2] {
+		case "h":
+			seconds = value * 3600
+		case "m":
+			seconds = value * 60
+		case "s":
+			seconds = value
+		case "ms":
+			seconds = value / 1000
+		case "f":
+			seconds = value / t.frameRate
+		case "t":
+			seconds = value / t.tickRate
+		}
+	} else {
+		return 0, errorf(ErrBadTime, "invalid time expression: %s", expression)
+	}
+
+	if seconds > float64(math.MaxInt64/int64(time.Second)) {
+		return 0, errorf(ErrOutOfRange, "time out of range: %s", expression)
+	}
+	return time.Duration(math.Round(seconds * float64(time.Second))), nil
+}
+
+// ttmlAttr returns the value of the attribute of token with the given local
+// name, in any namespace.
+func ttmlAttr(token xml.StartElement, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// ttmlStartTag formats the start tag of an element for diagnostics.
+func ttmlStartTag(token xml.StartElement) string {
+	var tag strings.Builder
+	tag.WriteString("<" + token.Name.Local)
+	for _, attr := range token.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		tag.WriteString(" " + attr.Name.Local + `="` + ttmlEscaper.Replace(attr.Value) + `"`)
+	}
+	tag.WriteString(">")
+	return tag.String()
+}
+
+// ttmlCueText collapses the runs of spaces in the collected text of a p
+// element and removes spaces and blank lines at its edges.
+func ttmlCueText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, func(r rune) bool { return r == ' ' }), " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
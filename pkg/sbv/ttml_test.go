@@ -0,0 +1,444 @@
+package sbv
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodeTTML returns subtitles encoded by a TTMLEncoder with opts.
+func encodeTTML(t *testing.T, subtitles []Subtitle, opts TTMLOptions) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := (TTMLEncoder{Options: opts}).Encode(&buf, subtitles); err != nil {
+		t.Fatalf("Encode() unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestTTMLEncoder(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 4*time.Second + 5*time.Millisecond, Text: "First <i>line</i>\nTom & Jerry <3"},
+		{StartTime: 1*time.Hour + 2*time.Minute + 3*time.Second, EndTime: 1*time.Hour + 2*time.Minute + 5*time.Second, Text: "<B>Bold</B>"},
+	}
+
+	got := encodeTTML(t, subtitles, TTMLOptions{Language: "en-GB", Title: "Episode 1"})
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:timeBase="media" ttp:profile="http://www.w3.org/ns/ttml/profile/imsc1/text" xml:lang="en-GB">
+  <head>
+    <metadata>
+      <ttm:title>Episode 1</ttm:title>
+    </metadata>
+    <styling>
+      <style xml:id="default" tts:fontFamily="proportionalSansSerif" tts:fontSize="100%" tts:lineHeight="125%" tts:color="white" tts:backgroundColor="black" tts:textAlign="center"/>
+    </styling>
+    <layout>
+      <region xml:id="bottom" tts:origin="10% 10%" tts:extent="80% 80%" tts:displayAlign="after"/>
+    </layout>
+  </head>
+  <body region="bottom" style="default">
+    <div>
+      <p begin="00:00:01.000" end="00:00:04.005">First <span tts:fontStyle="italic">line</span><br/>Tom &amp; Jerry &lt;3</p>
+      <p begin="01:02:03.000" end="01:02:05.000"><span tts:fontWeight="bold">Bold</span></p>
+    </div>
+  </body>
+</tt>
+`
+	if got != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+	assertWellFormedXML(t, got)
+}
+
+func TestTTMLEncoderEncoding(t *testing.T) {
+	subtitles := []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Café"}}
+
+	tests := []struct {
+		encoding    Encoding
+		declaration string
+	}{
+		{EncodingUTF8, `encoding="UTF-8"`},
+		{EncodingUTF8BOM, `encoding="UTF-8"`},
+		{EncodingUTF16LE, `encoding="UTF-16"`},
+		{EncodingUTF16BE, `encoding="UTF-16"`},
+		{EncodingWindows1252, `encoding="windows-1252"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := TTMLEncoder{Options: TTMLOptions{Encoding: tt.encoding}}
+			if err := encoder.Encode(NewTextWriter(&buf, tt.encoding), subtitles); err != nil {
+				t.Fatalf("Encode() unexpected error: %v", err)
+			}
+
+			// The declaration has to match the bytes written after it
+			text, err := io.ReadAll(NewTextReader(bytes.NewReader(buf.Bytes()), tt.encoding))
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(string(text), `<?xml version="1.0" `+tt.declaration+`?>`) {
+				t.Errorf("Encode() declaration = %q, want %s", strings.SplitN(string(text), "\n", 2)[0], tt.declaration)
+			}
+
+			got, err := (TTMLDecoder{}).Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil || len(got) != 1 || got[0].Text != "Café" {
+				t.Errorf("Decode() = %q, %v, want the cue back", got, err)
+			}
+		})
+	}
+}
+
+func TestTTMLText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Hello", "Hello"},
+		{"line break", "one\ntwo", "one<br/>two"},
+		{"escaping", `a < b & "c"`, "a &lt; b &amp; &quot;c&quot;"},
+		{"unknown tag escaped", `<font color="red">x</font>`, "&lt;font color=&quot;red&quot;&gt;x&lt;/font&gt;"},
+		{"unclosed tag", "<i>open", `<span tts:fontStyle="italic">open</span>`},
+		{"stray closing tag", "x</b>y", "xy"},
+		{
+			"misnested tags",
+			"<i>a<b>b<u>c</i>d",
+			`<span tts:fontStyle="italic">a<span tts:fontWeight="bold">b<span tts:textDecoration="underline">c</span></span></span>` +
+				`<span tts:fontWeight="bold"><span tts:textDecoration="underline">d</span></span>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ttmlText(tt.text); got != tt.want {
+				t.Errorf("ttmlText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTMLWriter(t *testing.T) {
+	// An empty document is still complete
+	var buf bytes.Buffer
+	writer := NewTTMLWriter(&buf, TTMLOptions{})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, `xml:lang="en"`) || !strings.HasSuffix(got, ttmlFooter) {
+		t.Errorf("empty TTMLWriter output = %q, want header with default language and footer", got)
+	}
+	assertWellFormedXML(t, buf.String())
+
+	buf.Reset()
+	writer = NewTTMLWriter(&buf, TTMLOptions{})
+	if err := writer.WriteCue(Subtitle{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}); err != nil {
+		t.Fatalf("WriteCue() unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() unexpected error: %v", err)
+	}
+	want := encodeTTML(t, []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "Hi"}}, TTMLOptions{})
+	if buf.String() != want {
+		t.Errorf("TTMLWriter output = %q, want %q", buf.String(), want)
+	}
+}
+
+// assertWellFormedXML fails the test if document is not well-formed XML.
+func assertWellFormedXML(t *testing.T, document string) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("document is not well-formed XML: %v", err)
+		}
+	}
+}
+
+func TestTTMLDecoder(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttp="http://www.w3.org/ns/ttml#parameter"
+    ttp:frameRate="25" xml:lang="en">
+  <head>
+    <styling>
+      <style xml:id="italic" tts:fontStyle="italic"/>
+    </styling>
+  </head>
+  <body begin="10s">
+    <div begin="00:00:01.000">
+      <p begin="00:00:01:05" end="00:00:02:00">Hello
+         <span style="italic">world</span><br/>
+         second   line</p>
+      <p begin="50f" dur="1.5s"><span tts:fontWeight="bold">Bold <span tts:fontStyle="italic">both</span></span> &amp; plain</p>
+      <p begin="1m" end="61500ms"><span tts:fontStyle="italic"> </span></p>
+    </div>
+  </body>
+</tt>
+`
+
+	got, err := TTMLDecoder{}.Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+
+	want := []Subtitle{
+		{StartTime: 12*time.Second + 200*time.Millisecond, EndTime: 13 * time.Second, Text: "Hello <i>world</i>\nsecond line"},
+		{StartTime: 13 * time.Second, EndTime: 14*time.Second + 500*time.Millisecond, Text: "<b>Bold <i>both</i></b> & plain"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Decode() returned %d subtitles, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("subtitle %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTTMLTimingParse(t *testing.T) {
+	ntsc := ttmlTiming{frameRate: 30 * 1000.0 / 1001, subFrameRate: 2, tickRate: 10000000}
+
+	tests := []struct {
+		expression string
+		timing     ttmlTiming
+		want       time.Duration
+		wantErr    error
+	}{
+		{expression: "01:02:03.5", want: 1*time.Hour + 2*time.Minute + 3*time.Second + 500*time.Millisecond},
+		{expression: "00:00:01.042", want: 1*time.Second + 42*time.Millisecond},
+		{expression: "100:00:00", want: 100 * time.Hour},
+		{expression: "00:00:01:15", timing: ttmlTiming{frameRate: 30}, want: 1*time.Second + 500*time.Millisecond},
+		{expression: "00:00:00:01.1", timing: ntsc, want: 50050000},
+		{expression: "30f", timing: ntsc, want: 1*time.Second + 1*time.Millisecond},
+		{expression: "25000000t", timing: ntsc, want: 2*time.Second + 500*time.Millisecond},
+		{expression: "1.5h", want: 90 * time.Minute},
+		{expression: "2m", want: 2 * time.Minute},
+		{expression: "0.25s", want: 250 * time.Millisecond},
+		{expression: "1500ms", want: 1500 * time.Millisecond},
+		{expression: "00:60:00.000", wantErr: ErrOutOfRange},
+		{expression: "1:02:03", wantErr: ErrBadTime},
+		{expression: "5", wantErr: ErrBadTime},
+		{expression: "-1s", wantErr: ErrBadTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := tt.timing.parse(tt.expression)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("parse(%q) error = %v, want %v", tt.expression, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse(%q) unexpected error: %v", tt.expression, err)
+			}
+			if got != tt.want {
+				t.Errorf("parse(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTMLDecoderParameters(t *testing.T) {
+	// 29.97 fps drop-frame rate and ticks counted in sub-frames
+	document := `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter"
+    ttp:frameRate="30" ttp:frameRateMultiplier="1000 1001" ttp:subFrameRate="2">
+  <body><div>
+    <p begin="30f" end="120t">Hi</p>
+  </div></body>
+</tt>`
+
+	got, err := TTMLDecoder{}.Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	want := Subtitle{StartTime: 1*time.Second + 1*time.Millisecond, EndTime: 2*time.Second + 2*time.Millisecond, Text: "Hi"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTTMLRoundTrip(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 2*time.Second + 500*time.Millisecond, Text: "<i>Italic</i> & <b>bold</b>\nsecond <u>line</u>"},
+		{StartTime: 1 * time.Hour, EndTime: 1*time.Hour + time.Second, Text: "Last"},
+	}
+	document := encodeTTML(t, subtitles, TTMLOptions{})
+
+	got, err := TTMLDecoder{}.Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if len(got) != len(subtitles) {
+		t.Fatalf("Decode() returned %d subtitles, want %d: %+v", len(got), len(subtitles), got)
+	}
+	for i := range subtitles {
+		if got[i] != subtitles[i] {
+			t.Errorf("subtitle %d = %+v, want %+v", i, got[i], subtitles[i])
+		}
+	}
+}
+
+func TestTTMLDecoderOpenEnded(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Subtitle
+	}{
+		{
+			name: "end of the enclosing div",
+			body: `<div begin="10s" end="20s"><p begin="1s">A</p></div>`,
+			want: []Subtitle{{StartTime: 11 * time.Second, EndTime: 20 * time.Second, Text: "A"}},
+		},
+		{
+			name: "until the next p begins",
+			body: `<div><p begin="1s">A</p><p begin="3s" end="4s">B</p></div>`,
+			want: []Subtitle{
+				{StartTime: 1 * time.Second, EndTime: 3 * time.Second, Text: "A"},
+				{StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "B"},
+			},
+		},
+		{
+			name: "last p until the latest end",
+			body: `<div><p begin="1s" end="5s">A</p><p begin="2s">B</p></div>`,
+			want: []Subtitle{
+				{StartTime: 1 * time.Second, EndTime: 5 * time.Second, Text: "A"},
+				{StartTime: 2 * time.Second, EndTime: 5 * time.Second, Text: "B"},
+			},
+		},
+		{
+			name: "no end anywhere",
+			body: `<p begin="1s">A</p><p begin="2s">B</p>`,
+			want: []Subtitle{
+				{StartTime: 1 * time.Second, EndTime: 2 * time.Second, Text: "A"},
+				{StartTime: 2 * time.Second, EndTime: 2 * time.Second, Text: "B"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := `<tt xmlns="http://www.w3.org/ns/ttml"><body>` + tt.body + `</body></tt>`
+
+			got, err := TTMLDecoder{}.Decode(strings.NewReader(document))
+			if err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Decode() returned %d subtitles, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("subtitle %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTTMLDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		wantErr  error
+	}{
+		{
+			name:     "bad time",
+			document: `<tt xmlns="http://www.w3.org/ns/ttml"><body><p begin="1x" end="2s">Hi</p></body></tt>`,
+			wantErr:  ErrBadTime,
+		},
+		{
+			name:     "bad frame rate",
+			document: `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="fast"><body><p begin="1s" end="2s">Hi</p></body></tt>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TTMLDecoder{}.Decode(strings.NewReader(tt.document))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Decode() error = %v, want *ParseError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if parseErr.Line != 1 {
+				t.Errorf("ParseError line = %d, want 1", parseErr.Line)
+			}
+		})
+	}
+
+	if _, err := (TTMLDecoder{}).Decode(strings.NewReader("<tt><body>")); err == nil {
+		t.Error("Decode() expected error for a truncated document, got nil")
+	}
+}
+
+func TestTTMLDecoderLenient(t *testing.T) {
+	document := `<tt xmlns="http://www.w3.org/ns/ttml">
+  <body>
+    <p begin="1s" end="2s">First</p>
+    <p begin="bad" end="4s">Skipped <span>with children</span></p>
+    <p begin="6s" end="5s">Swapped</p>
+  </body>
+</tt>`
+
+	got, diagnostics, err := TTMLDecoder{}.DecodeLenient(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("DecodeLenient() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[1].StartTime != 5*time.Second || got[1].EndTime != 6*time.Second {
+		t.Errorf("DecodeLenient() = %+v, want the first cue and the swapped cue", got)
+	}
+	if len(diagnostics) != 2 || diagnostics[0].Severity != SeverityError || diagnostics[0].Line != 4 ||
+		diagnostics[1].Severity != SeverityWarning || diagnostics[1].Line != 5 {
+		t.Errorf("DecodeLenient() diagnostics = %+v, want an error on line 4 and a warning on line 5", diagnostics)
+	}
+}
+
+func TestTTMLDecoderLenientDocument(t *testing.T) {
+	document := `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="fast">
+  <body>
+    <div begin="bad"><p begin="1s" end="2s">Skipped</p></div>
+    <div><p begin="15f" end="2s">Kept</p></div>
+  </body>
+</tt>`
+
+	got, diagnostics, err := TTMLDecoder{}.DecodeLenient(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("DecodeLenient() unexpected error: %v", err)
+	}
+	// The default frame rate of 30 is used
+	want := Subtitle{StartTime: 500 * time.Millisecond, EndTime: 2 * time.Second, Text: "Kept"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("DecodeLenient() = %+v, want %+v", got, want)
+	}
+
+	wantDiagnostics := []struct {
+		severity Severity
+		action   string
+	}{
+		{SeverityWarning, "used the default frameRate for the document"},
+		{SeverityError, "skipped div element and the cues in it"},
+	}
+	if len(diagnostics) != len(wantDiagnostics) {
+		t.Fatalf("DecodeLenient() diagnostics = %+v, want %d", diagnostics, len(wantDiagnostics))
+	}
+	for i, want := range wantDiagnostics {
+		if diagnostics[i].Severity != want.severity || diagnostics[i].Action != want.action {
+			t.Errorf("diagnostic %d = %+v, want %v %q", i, diagnostics[i], want.severity, want.action)
+		}
+	}
+}
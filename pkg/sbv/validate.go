@@ -0,0 +1,155 @@
+package sbv
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// IssueKind identifies the rule an Issue breaks.
+type IssueKind string
+
+// Rules checked by Validate.
+const (
+	IssueEndBeforeStart IssueKind = "end-before-start"
+	IssueZeroDuration   IssueKind = "zero-duration"
+	IssueOutOfOrder     IssueKind = "out-of-order"
+	IssueOverlap        IssueKind = "overlap"
+	IssueReadingSpeed   IssueKind = "reading-speed"
+	IssueLineLength     IssueKind = "line-length"
+	IssueLineCount      IssueKind = "line-count"
+	IssueEmptyText      IssueKind = "empty-text"
+
+	// IssueParse is not checked by Validate. It marks a problem reported by
+	// lenient parsing, see Diagnostic.Issue.
+	IssueParse IssueKind = "parse"
+)
+
+// Issue describes a problem found by Validate.
+type Issue struct {
+	// Cue is the 1-based number of the offending cue, or 0 for problems
+	// found while parsing.
+	Cue int `json:"cue"`
+
+	// Line is the 1-based input line of a problem found while parsing, or 0.
+	Line int `json:"line,omitempty"`
+
+	// Kind is the rule the cue breaks.
+	Kind IssueKind `json:"kind"`
+
+	// Severity is SeverityError for cues players cannot show correctly and
+	// SeverityWarning for style problems.
+	Severity Severity `json:"severity"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// String formats the issue as "cue N: severity: message [kind]", or with
+// "line N" for problems found while parsing.
+func (i Issue) String() string {
+	if i.Cue == 0 && i.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s [%s]", i.Line, i.Severity, i.Message, i.Kind)
+	}
+	return fmt.Sprintf("cue %d: %s: %s [%s]", i.Cue, i.Severity, i.Message, i.Kind)
+}
+
+// ValidationOptions holds the thresholds used by ValidateWithOptions. A zero
+// threshold disables its check.
+type ValidationOptions struct {
+	// MaxCharsPerSecond is the highest reading speed allowed, counting every
+	// character of the text except line breaks.
+	MaxCharsPerSecond float64
+
+	// MaxLineLength is the most characters allowed on one line.
+	MaxLineLength int
+
+	// MaxLines is the most lines allowed in one cue.
+	MaxLines int
+}
+
+// DefaultValidationOptions returns the thresholds used by Validate, which
+// follow common broadcast guidelines.
+func DefaultValidationOptions() ValidationOptions {
+	return ValidationOptions{
+		MaxCharsPerSecond: 21,
+		MaxLineLength:     42,
+		MaxLines:          2,
+	}
+}
+
+// Validate checks subtitles against DefaultValidationOptions.
+func Validate(subtitles []Subtitle) []Issue {
+	return ValidateWithOptions(subtitles, DefaultValidationOptions())
+}
+
+// ValidateWithOptions checks subtitles for timing and layout problems and
+// returns them in cue order.
+func ValidateWithOptions(subtitles []Subtitle, opts ValidationOptions) []Issue {
+	converter := NewConverter()
+
+	var issues []Issue
+	report := func(cue int, kind IssueKind, severity Severity, format string, args ...any) {
+		issues = append(issues, Issue{
+			Cue:      cue,
+			Kind:     kind,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, subtitle := range subtitles {
+		cue := i + 1
+		duration := subtitle.EndTime - subtitle.StartTime
+
+		switch {
+		case duration < 0:
+			report(cue, IssueEndBeforeStart, SeverityError, "end time %s is before start time %s",
+				converter.formatSRTTime(subtitle.EndTime), converter.formatSRTTime(subtitle.StartTime))
+		case duration == 0:
+			report(cue, IssueZeroDuration, SeverityError, "cue has zero duration at %s",
+				converter.formatSRTTime(subtitle.StartTime))
+		}
+
+		if i > 0 {
+			previous := subtitles[i-1]
+			switch {
+			case subtitle.StartTime < previous.StartTime:
+				report(cue, IssueOutOfOrder, SeverityError, "starts at %s, before cue %d at %s",
+					converter.formatSRTTime(subtitle.StartTime), cue-1, converter.formatSRTTime(previous.StartTime))
+			case subtitle.StartTime < previous.EndTime:
+				report(cue, IssueOverlap, SeverityWarning, "starts at %s, before cue %d ends at %s",
+					converter.formatSRTTime(subtitle.StartTime), cue-1, converter.formatSRTTime(previous.EndTime))
+			}
+		}
+
+		if strings.TrimSpace(subtitle.Text) == "" {
+			report(cue, IssueEmptyText, SeverityWarning, "cue has no text")
+			continue
+		}
+
+		lines := strings.Split(subtitle.Text, "\n")
+		if opts.MaxLines > 0 && len(lines) > opts.MaxLines {
+			report(cue, IssueLineCount, SeverityWarning, "%d lines, more than %d", len(lines), opts.MaxLines)
+		}
+		characters := 0
+		for n, line := range lines {
+			length := utf8.RuneCountInString(line)
+			characters += length
+			if opts.MaxLineLength > 0 && length > opts.MaxLineLength {
+				report(cue, IssueLineLength, SeverityWarning, "line %d has %d characters, more than %d",
+					n+1, length, opts.MaxLineLength)
+			}
+		}
+
+		if opts.MaxCharsPerSecond > 0 && duration > 0 {
+			cps := float64(characters) / duration.Seconds()
+			if cps > opts.MaxCharsPerSecond {
+				report(cue, IssueReadingSpeed, SeverityWarning, "%.1f characters per second, more than %g",
+					cps, opts.MaxCharsPerSecond)
+			}
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,116 @@
+package sbv
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// issueKinds returns "cue:kind" for every issue.
+func issueKinds(issues []Issue) []string {
+	kinds := make([]string, len(issues))
+	for i, issue := range issues {
+		kinds[i] = fmt.Sprintf("%d:%s", issue.Cue, issue.Kind)
+	}
+	return kinds
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		subtitles []Subtitle
+		want      []string
+	}{
+		{
+			name: "clean",
+			subtitles: []Subtitle{
+				{StartTime: 1 * time.Second, EndTime: 3 * time.Second, Text: "Hello there"},
+				{StartTime: 3 * time.Second, EndTime: 5 * time.Second, Text: "General Kenobi"},
+			},
+		},
+		{
+			name: "timing problems",
+			subtitles: []Subtitle{
+				{StartTime: 5 * time.Second, EndTime: 4 * time.Second, Text: "Backwards"},
+				{StartTime: 6 * time.Second, EndTime: 6 * time.Second, Text: "Instant"},
+				{StartTime: 2 * time.Second, EndTime: 3 * time.Second, Text: "Early"},
+				{StartTime: 2500 * time.Millisecond, EndTime: 4 * time.Second, Text: "Overlapping"},
+			},
+			want: []string{
+				"1:end-before-start",
+				"2:zero-duration",
+				"3:out-of-order",
+				"4:overlap",
+			},
+		},
+		{
+			name: "layout problems",
+			subtitles: []Subtitle{
+				{StartTime: 0, EndTime: 1 * time.Second, Text: "This line is read far too quickly"},
+				{StartTime: 2 * time.Second, EndTime: 10 * time.Second, Text: "One\nTwo\nThree"},
+				{StartTime: 10 * time.Second, EndTime: 20 * time.Second, Text: strings.Repeat("x", 43)},
+				{StartTime: 20 * time.Second, EndTime: 21 * time.Second, Text: "  "},
+			},
+			want: []string{
+				"1:reading-speed",
+				"2:line-count",
+				"3:line-length",
+				"4:empty-text",
+			},
+		},
+		{
+			name: "characters are counted as runes",
+			subtitles: []Subtitle{
+				{StartTime: 0, EndTime: 10 * time.Second, Text: strings.Repeat("é", 42)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := issueKinds(Validate(tt.subtitles))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWithOptions(t *testing.T) {
+	subtitles := []Subtitle{
+		{StartTime: 0, EndTime: 1 * time.Second, Text: "Twelve chars\nand a second line"},
+	}
+
+	if issues := ValidateWithOptions(subtitles, ValidationOptions{}); len(issues) != 0 {
+		t.Errorf("ValidateWithOptions() with checks disabled = %v, want none", issues)
+	}
+
+	opts := ValidationOptions{MaxCharsPerSecond: 10, MaxLineLength: 12, MaxLines: 1}
+	want := []string{"1:line-count", "1:line-length", "1:reading-speed"}
+	if got := issueKinds(ValidateWithOptions(subtitles, opts)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateWithOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestIssueJSON(t *testing.T) {
+	issue := Issue{Cue: 3, Kind: IssueOverlap, Severity: SeverityWarning, Message: "overlaps"}
+
+	data, err := json.Marshal(issue)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"cue":3,"kind":"overlap","severity":"warning","message":"overlaps"}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	if got := issue.String(); got != "cue 3: warning: overlaps [overlap]" {
+		t.Errorf("String() = %q", got)
+	}
+}
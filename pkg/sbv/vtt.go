@@ -0,0 +1,112 @@
+package sbv
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VTTOptions controls the optional parts of a WebVTT cue.
+type VTTOptions struct {
+	// CueIdentifiers writes a 1-based sequence number above each cue timing line.
+	CueIdentifiers bool
+
+	// CueSettings is appended to every cue timing line, e.g. "line:90% align:center".
+	CueSettings string
+}
+
+// vttHeader is the signature every WebVTT file starts with, followed by a blank line.
+const vttHeader = "WEBVTT\n\n"
+
+// markupTagPattern matches anything shaped like an HTML tag, such as <i> or
+// <font color="red">.
+var markupTagPattern = regexp.MustCompile(`</?[A-Za-z][^<>]*>`)
+
+// vttTagPattern matches the markup tags WebVTT cue text supports as written.
+var vttTagPattern = regexp.MustCompile(`(?i)^<(/?)([ibu])>$`)
+
+// vttEscaper escapes the text WebVTT cue text cannot contain literally.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", "-->", "--&gt;")
+
+// ConvertToVTT converts parsed subtitles to WebVTT format string.
+func (c *DefaultConverter) ConvertToVTT(subtitles []Subtitle, opts VTTOptions) string {
+	var result strings.Builder
+	result.Grow(len(subtitles)*100 + len(vttHeader)) // Pre-allocate approximate capacity
+
+	result.WriteString(vttHeader)
+	for i, subtitle := range subtitles {
+		c.writeVTTCue(&result, c.startIndex+i, subtitle, opts)
+	}
+
+	return c.withLineEnding(result.String())
+}
+
+// writeVTTCue formats a single WebVTT cue with the given identifier number.
+func (c *DefaultConverter) writeVTTCue(buf cueBuffer, index int, subtitle Subtitle, opts VTTOptions) {
+	// Optional cue identifier (1-based unless configured otherwise)
+	if opts.CueIdentifiers {
+		buf.WriteString(strconv.Itoa(index))
+		buf.WriteByte('\n')
+	}
+
+	// WebVTT timestamp format: HH:MM:SS.mmm --> HH:MM:SS.mmm [settings]
+	buf.WriteString(c.formatVTTTime(subtitle.StartTime))
+	buf.WriteString(" --> ")
+	buf.WriteString(c.formatVTTTime(subtitle.EndTime))
+	if opts.CueSettings != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(opts.CueSettings)
+	}
+	buf.WriteByte('\n')
+
+	// Subtitle text
+	buf.WriteString(vttText(subtitle.Text))
+	buf.WriteString("\n\n")
+}
+
+// WriteVTTToFile converts subtitles and writes them directly to a WebVTT file.
+func (c *DefaultConverter) WriteVTTToFile(subtitles []Subtitle, filename string, opts VTTOptions) error {
+	return WriteFileAtomic(filename, func(writer io.Writer) error {
+		return c.WriteVTTToWriter(subtitles, writer, opts)
+	})
+}
+
+// WriteVTTToWriter converts subtitles and writes them to an io.Writer in WebVTT format.
+// Each cue is written as soon as it is formatted.
+func (c *DefaultConverter) WriteVTTToWriter(subtitles []Subtitle, writer io.Writer, opts VTTOptions) error {
+	return writeCues(newVTTWriter(c.textWriter(writer), c, opts), subtitles)
+}
+
+// formatVTTTime formats a time.Duration to WebVTT timestamp format (HH:MM:SS.mmm).
+func (c *DefaultConverter) formatVTTTime(duration time.Duration) string {
+	hours, minutes, seconds, milliseconds := splitDuration(duration)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds)
+}
+
+// vttText converts cue text to WebVTT cue text. <i>, <b> and <u> are kept,
+// other tags such as <font> are removed, & and < are escaped as entities and
+// "-->" becomes "--&gt;". Blank lines, which would end the cue, are dropped.
+func vttText(text string) string {
+	var result strings.Builder
+	last := 0
+	for _, match := range markupTagPattern.FindAllStringIndex(text, -1) {
+		result.WriteString(vttEscaper.Replace(text[last:match[0]]))
+		last = match[1]
+		if tag := vttTagPattern.FindStringSubmatch(text[match[0]:match[1]]); tag != nil {
+			result.WriteString("<" + tag[1] + strings.ToLower(tag[2]) + ">")
+		}
+	}
+	result.WriteString(vttEscaper.Replace(text[last:]))
+
+	lines := strings.Split(result.String(), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
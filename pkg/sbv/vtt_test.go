@@ -0,0 +1,163 @@
+package sbv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestConvertToVTT(t *testing.T) {
+	converter := NewConverter()
+
+	subtitles := []Subtitle{
+		{
+			StartTime: 1 * time.Second,
+			EndTime:   4 * time.Second,
+			Text:      "First subtitle",
+		},
+		{
+			StartTime: 1*time.Hour + 5*time.Second + 500*time.Millisecond,
+			EndTime:   1*time.Hour + 8*time.Second + 200*time.Millisecond,
+			Text:      "Second subtitle\nwith multiple lines",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     VTTOptions
+		expected string
+	}{
+		{
+			name: "default options",
+			opts: VTTOptions{},
+			expected: `WEBVTT
+
+00:00:01.000 --> 00:00:04.000
+First subtitle
+
+01:00:05.500 --> 01:00:08.200
+Second subtitle
+with multiple lines
+
+`,
+		},
+		{
+			name: "cue identifiers and settings",
+			opts: VTTOptions{CueIdentifiers: true, CueSettings: "line:90% align:center"},
+			expected: `WEBVTT
+
+1
+00:00:01.000 --> 00:00:04.000 line:90% align:center
+First subtitle
+
+2
+01:00:05.500 --> 01:00:08.200 line:90% align:center
+Second subtitle
+with multiple lines
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := converter.ConvertToVTT(subtitles, tt.opts)
+			if result != tt.expected {
+				t.Errorf("ConvertToVTT() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertToVTTEmpty(t *testing.T) {
+	converter := NewConverter()
+
+	result := converter.ConvertToVTT(nil, VTTOptions{})
+	if result != "WEBVTT\n\n" {
+		t.Errorf("ConvertToVTT() = %q, want header only", result)
+	}
+}
+
+func TestVTTText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Hello\nworld", "Hello\nworld"},
+		{"arrow and entities", "x --> y & <z", "x --&gt; y &amp; &lt;z"},
+		{"supported tags kept", "<I>a</I> <b>b</b> <u>c</u>", "<i>a</i> <b>b</b> <u>c</u>"},
+		{"unsupported tags removed", `<font color="red">red</font> <s>gone</s>`, "red gone"},
+		{"blank lines dropped", "one\n\n  \ntwo", "one\ntwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vttText(tt.text); got != tt.want {
+				t.Errorf("vttText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	got := NewConverter().ConvertToVTT([]Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "x --> y & <z"}}, VTTOptions{})
+	if want := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nx --&gt; y &amp; &lt;z\n\n"; got != want {
+		t.Errorf("ConvertToVTT() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatVTTTime(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{
+			name:     "zero duration",
+			duration: 0,
+			expected: "00:00:00.000",
+		},
+		{
+			name:     "24 hours",
+			duration: 24 * time.Hour,
+			expected: "24:00:00.000",
+		},
+		{
+			name:     "100 hours",
+			duration: 100*time.Hour + 250*time.Millisecond,
+			expected: "100:00:00.250",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := converter.formatVTTTime(tt.duration)
+			if result != tt.expected {
+				t.Errorf("formatVTTTime() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteVTTToWriter(t *testing.T) {
+	converter := NewConverter()
+
+	subtitles := []Subtitle{
+		{
+			StartTime: 1 * time.Second,
+			EndTime:   4 * time.Second,
+			Text:      "Single subtitle",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := converter.WriteVTTToWriter(subtitles, &buf, VTTOptions{}); err != nil {
+		t.Fatalf("WriteVTTToWriter() unexpected error: %v", err)
+	}
+
+	expected := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nSingle subtitle\n\n"
+	if buf.String() != expected {
+		t.Errorf("WriteVTTToWriter() wrote %q, want %q", buf.String(), expected)
+	}
+}
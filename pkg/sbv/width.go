@@ -0,0 +1,96 @@
+package sbv
+
+import "unicode"
+
+// wideRanges lists the East Asian Wide and Fullwidth code points, which take
+// two columns on screen: CJK ideographs, kana, hangul, fullwidth forms and
+// emoji.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initial consonants
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media control symbols
+	{0x23F0, 0x23F3},   // alarm clock, stopwatch
+	{0x25FD, 0x25FE},   // medium small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac signs
+	{0x26AA, 0x26AB},   // medium circles
+	{0x26BD, 0x26BE},   // soccer ball, baseball
+	{0x26C4, 0x26C5},   // snowman, sun behind cloud
+	{0x2705, 0x2705},   // check mark
+	{0x270A, 0x270B},   // raised fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x2753, 0x2755},   // question and exclamation marks
+	{0x2795, 0x2797},   // heavy plus, minus, division
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2E80, 0x303E},   // CJK radicals, punctuation
+	{0x3041, 0x33FF},   // kana, bopomofo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x16FE0, 0x16FE4}, // ideographic symbols
+	{0x17000, 0x18AFF}, // Tangut
+	{0x1B000, 0x1B16F}, // kana supplement and extensions
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // playing card
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // squared latin words
+	{0x1F200, 0x1F251}, // enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // large coloured circles and squares
+	{0x1F90C, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended A
+	{0x20000, 0x2FFFD}, // CJK extensions B to F
+	{0x30000, 0x3FFFD}, // CJK extension G and beyond
+}
+
+// runeWidth returns the number of columns r takes on screen: 0 for
+// combining marks and other zero-width characters, 2 for wide East Asian
+// characters and 1 otherwise.
+func runeWidth(r rune) int {
+	if isZeroWidth(r) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r < wide.lo {
+			break
+		}
+		if r <= wide.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// isZeroWidth reports whether r is drawn on top of the previous character
+// rather than taking a column of its own.
+func isZeroWidth(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F: // variation selectors
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // skin tone modifiers
+		return true
+	}
+	return false
+}
+
+// DisplayWidth returns the number of columns s takes on screen, counting
+// wide East Asian characters as two columns and combining marks as none.
+func DisplayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
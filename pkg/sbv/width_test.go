@@ -0,0 +1,29 @@
+package sbv
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "ascii", input: "Hello", want: 5},
+		{name: "accented", input: "café", want: 4},
+		{name: "combining mark", input: "café", want: 4},
+		{name: "chinese", input: "你好", want: 4},
+		{name: "japanese kana", input: "こんにちは", want: 10},
+		{name: "hangul", input: "안녕", want: 4},
+		{name: "fullwidth", input: "ＡＢ", want: 4},
+		{name: "emoji with skin tone", input: "👍🏽", want: 2},
+		{name: "mixed", input: "Tokyo 東京", want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisplayWidth(tt.input); got != tt.want {
+				t.Errorf("DisplayWidth(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package sbv
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// youtubePen is the text styling of a YouTube timedtext pen.
+type youtubePen struct {
+	bold, italic, underline bool
+}
+
+// youtubeSegment is a run of text within a caption event. Automatic captions
+// have a segment per word, timed from the start of the event.
+type youtubeSegment struct {
+	text   string
+	offset time.Duration
+	pen    youtubePen
+}
+
+// youtubeEvent is a caption shown from start for duration.
+type youtubeEvent struct {
+	start    time.Duration
+	duration time.Duration
+	segments []youtubeSegment
+}
+
+// SRV3Decoder reads YouTube timedtext documents in SRV3 format, the XML
+// format YouTube serves captions in with fmt=srv3.
+type SRV3Decoder struct {
+	// Words makes every timed segment its own cue, lasting until the next
+	// segment starts. Automatic captions time each word this way. By default
+	// each p element is one cue.
+	Words bool
+}
+
+// Decode parses reader.
+func (d SRV3Decoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	events, err := parseSRV3(reader)
+	if err != nil {
+		return nil, err
+	}
+	return youtubeSubtitles(events, d.Words), nil
+}
+
+// JSON3Decoder reads YouTube timedtext documents in JSON3 format, the JSON
+// format YouTube serves captions in with fmt=json3.
+type JSON3Decoder struct {
+	// Words makes every timed segment its own cue, lasting until the next
+	// segment starts. Automatic captions time each word this way. By default
+	// each event is one cue.
+	Words bool
+}
+
+// Decode parses reader.
+func (d JSON3Decoder) Decode(reader io.Reader) ([]Subtitle, error) {
+	events, err := parseJSON3(reader)
+	if err != nil {
+		return nil, err
+	}
+	return youtubeSubtitles(events, d.Words), nil
+}
+
+// parseSRV3 reads the p elements of an SRV3 document. Their t and d
+// attributes are the start and duration in milliseconds, and the t
+// attributes of nested s elements offset them from the start of the p.
+// Pens declared in the head style the text of elements that refer to them.
+func parseSRV3(reader io.Reader) ([]youtubeEvent, error) {
+	reader = autoTextReader(reader)
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var (
+		events  []youtubeEvent
+		pens    = map[string]youtubePen{}
+		event   *youtubeEvent
+		segment *youtubeSegment
+	)
+	milliseconds := func(token xml.StartElement, name string) (time.Duration, error) {
+		value := srv3Attr(token, name)
+		if value == "" {
+			return 0, nil
+		}
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || ms < 0 {
+			line, column := decoder.InputPos()
+			return 0, &ParseError{Line: line, Column: column, Raw: value, Err: errorf(ErrBadTime, "invalid time in milliseconds: %s", value)}
+		}
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			line, column := decoder.InputPos()
+			return nil, &ParseError{Line: line, Column: column, Err: fmt.Errorf("invalid SRV3 document: %w", err)}
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "pen":
+				pens[srv3Attr(token, "id")] = youtubePen{
+					bold:      srv3Attr(token, "b") == "1",
+					italic:    srv3Attr(token, "i") == "1",
+					underline: srv3Attr(token, "u") == "1",
+				}
+			case "p":
+				start, err := milliseconds(token, "t")
+				if err != nil {
+					return nil, err
+				}
+				duration, err := milliseconds(token, "d")
+				if err != nil {
+					return nil, err
+				}
+				event = &youtubeEvent{start: start, duration: duration}
+				segment = &youtubeSegment{pen: pens[srv3Attr(token, "p")]}
+			case "s":
+				if event == nil {
+					continue
+				}
+				offset, err := milliseconds(token, "t")
+				if err != nil {
+					return nil, err
+				}
+				pen := segment.pen
+				if id := srv3Attr(token, "p"); id != "" {
+					pen = pens[id]
+				}
+				event.segments = append(event.segments, *segment)
+				segment = &youtubeSegment{offset: offset, pen: pen}
+			case "br":
+				if segment != nil {
+					segment.text += "\n"
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case token.Name.Local == "p" && event != nil:
+				event.segments = append(event.segments, *segment)
+				events = append(events, *event)
+				event, segment = nil, nil
+			case token.Name.Local == "s" && event != nil:
+				// Text after a segment belongs to the paragraph again
+				event.segments = append(event.segments, *segment)
+				segment = &youtubeSegment{offset: segment.offset, pen: segment.pen}
+			}
+		case xml.CharData:
+			if segment != nil {
+				segment.text += string(token)
+			}
+		}
+	}
+}
+
+// srv3Attr returns the value of the attribute of token with the given name.
+func srv3Attr(token xml.StartElement, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// json3Document is the part of a JSON3 document that holds captions.
+type json3Document struct {
+	Pens []struct {
+		Bold      int `json:"bAttr"`
+		Italic    int `json:"iAttr"`
+		Underline int `json:"uAttr"`
+	} `json:"pens"`
+	Events []struct {
+		Start    int64 `json:"tStartMs"`
+		Duration int64 `json:"dDurationMs"`
+		Pen      int   `json:"pPenId"`
+		Segments []struct {
+			Text   string `json:"utf8"`
+			Offset int64  `json:"tOffsetMs"`
+			Pen    *int   `json:"pPenId"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+// parseJSON3 reads the events of a JSON3 document. Times are in
+// milliseconds; segment offsets count from the start of their event. Events
+// without segments only position caption windows and have no text.
+func parseJSON3(reader io.Reader) ([]youtubeEvent, error) {
+	reader = autoTextReader(reader)
+
+	var document json3Document
+	if err := json.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid JSON3 document: %w", err)
+	}
+
+	pen := func(id int) youtubePen {
+		if id < 0 || id >= len(document.Pens) {
+			return youtubePen{}
+		}
+		p := document.Pens[id]
+		return youtubePen{bold: p.Bold == 1, italic: p.Italic == 1, underline: p.Underline == 1}
+	}
+
+	events := make([]youtubeEvent, 0, len(document.Events))
+	for i, e := range document.Events {
+		if e.Start < 0 || e.Duration < 0 {
+			return nil, fmt.Errorf("event %d: %w", i+1, errorf(ErrBadTime, "negative time: start %d, duration %d", e.Start, e.Duration))
+		}
+		event := youtubeEvent{
+			start:    time.Duration(e.Start) * time.Millisecond,
+			duration: time.Duration(e.Duration) * time.Millisecond,
+		}
+		for _, s := range e.Segments {
+			segment := youtubeSegment{text: s.Text, offset: time.Duration(max(s.Offset, 0)) * time.Millisecond, pen: pen(e.Pen)}
+			if s.Pen != nil {
+				segment.pen = pen(*s.Pen)
+			}
+			event.segments = append(event.segments, segment)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// youtubeSubtitles turns caption events into cues, one per event or, with
+// words, one per timed segment. Events and segments without text are left out.
+func youtubeSubtitles(events []youtubeEvent, words bool) []Subtitle {
+	var subtitles []Subtitle
+	for _, event := range events {
+		end := event.start + event.duration
+		if !words {
+			if text := youtubeText(event.segments); text != "" {
+				subtitles = append(subtitles, Subtitle{StartTime: event.start, EndTime: end, Text: text})
+			}
+			continue
+		}
+
+		var segments []youtubeSegment
+		for _, segment := range event.segments {
+			if strings.TrimSpace(segment.text) != "" {
+				segments = append(segments, segment)
+			}
+		}
+		for i, segment := range segments {
+			// Segments sharing an offset are shown until a later one starts
+			segmentEnd := end
+			for _, next := range segments[i+1:] {
+				if next.offset > segment.offset {
+					segmentEnd = min(event.start+next.offset, end)
+					break
+				}
+			}
+			subtitles = append(subtitles, Subtitle{
+				StartTime: min(event.start+segment.offset, segmentEnd),
+				EndTime:   segmentEnd,
+				Text:      youtubeText([]youtubeSegment{segment}),
+			})
+		}
+	}
+	return subtitles
+}
+
+// youtubeText joins the text of segments, with <b>, <i> and <u> markup where
+// their pens change styling, and trims spaces and blank lines at the edges of
+// the result and of every line.
+func youtubeText(segments []youtubeSegment) string {
+	var (
+		result  strings.Builder
+		current youtubePen
+	)
+	writeTags := func(pen youtubePen, closing bool) {
+		tags := []struct {
+			name string
+			on   bool
+		}{{"b", pen.bold}, {"i", pen.italic}, {"u", pen.underline}}
+		if closing {
+			slices.Reverse(tags)
+		}
+		for _, tag := range tags {
+			if !tag.on {
+				continue
+			}
+			if closing {
+				result.WriteString("</" + tag.name + ">")
+			} else {
+				result.WriteString("<" + tag.name + ">")
+			}
+		}
+	}
+
+	for _, segment := range segments {
+		text := segment.text
+		if text == "" {
+			continue
+		}
+		if segment.pen != current {
+			// Leading spaces separate words, so they stay outside the new styling
+			writeTags(current, true)
+			trimmed := strings.TrimLeft(text, " ")
+			result.WriteString(text[:len(text)-len(trimmed)])
+			writeTags(segment.pen, false)
+			text, current = trimmed, segment.pen
+		}
+		result.WriteString(text)
+	}
+	writeTags(current, true)
+
+	lines := strings.Split(strings.ReplaceAll(result.String(), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if strings.TrimSpace(htmlStylePattern.ReplaceAllString(text, "")) == "" {
+		return ""
+	}
+	return text
+}
@@ -0,0 +1,160 @@
+package sbv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// srv3Export is an SRV3 export with a styled manual caption followed by a
+// word-timed automatic caption.
+const srv3Export = `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3">
+<head>
+<pen id="1" i="1"/>
+<pen id="2" b="1"/>
+</head>
+<body>
+<p t="1000" d="2500">Tom &amp; Jerry
+<s p="1">in color</s></p>
+<p t="4000" d="1500" w="1"><s ac="0">so</s><s t="400" ac="0"> what</s><s t="900" p="2" ac="0"> now</s></p>
+<p t="5500" d="500" w="1" a="1">
+</p>
+</body>
+</timedtext>
+`
+
+// json3Export is the JSON3 export of the same captions as srv3Export.
+const json3Export = `{
+  "wireMagic": "pb3",
+  "pens": [ {  }, { "iAttr": 1 }, { "bAttr": 1 } ],
+  "wsWinStyles": [ {  } ],
+  "events": [ {
+    "tStartMs": 0,
+    "dDurationMs": 6000,
+    "id": 1,
+    "wpWinPosId": 1,
+    "wsWinStyleId": 1
+  }, {
+    "tStartMs": 1000,
+    "dDurationMs": 2500,
+    "wWinId": 1,
+    "segs": [ { "utf8": "Tom & Jerry\n" }, { "utf8": "in color", "pPenId": 1 } ]
+  }, {
+    "tStartMs": 4000,
+    "dDurationMs": 1500,
+    "wWinId": 1,
+    "segs": [ { "utf8": "so", "acAsrConf": 0 }, { "utf8": " what", "tOffsetMs": 400, "acAsrConf": 0 }, { "utf8": " now", "tOffsetMs": 900, "pPenId": 2 } ]
+  }, {
+    "tStartMs": 5500,
+    "dDurationMs": 500,
+    "wWinId": 1,
+    "aAppend": 1,
+    "segs": [ { "utf8": "\n" } ]
+  } ]
+}
+`
+
+func TestYouTubeDecoders(t *testing.T) {
+	events := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 3*time.Second + 500*time.Millisecond, Text: "Tom & Jerry\n<i>in color</i>"},
+		{StartTime: 4 * time.Second, EndTime: 5*time.Second + 500*time.Millisecond, Text: "so what <b>now</b>"},
+	}
+	words := []Subtitle{
+		{StartTime: 1 * time.Second, EndTime: 3*time.Second + 500*time.Millisecond, Text: "Tom & Jerry"},
+		{StartTime: 1 * time.Second, EndTime: 3*time.Second + 500*time.Millisecond, Text: "<i>in color</i>"},
+		{StartTime: 4 * time.Second, EndTime: 4*time.Second + 400*time.Millisecond, Text: "so"},
+		{StartTime: 4*time.Second + 400*time.Millisecond, EndTime: 4*time.Second + 900*time.Millisecond, Text: "what"},
+		{StartTime: 4*time.Second + 900*time.Millisecond, EndTime: 5*time.Second + 500*time.Millisecond, Text: "<b>now</b>"},
+	}
+
+	tests := []struct {
+		name     string
+		decoder  Decoder
+		document string
+		want     []Subtitle
+	}{
+		{name: "srv3", decoder: SRV3Decoder{}, document: srv3Export, want: events},
+		{name: "srv3 words", decoder: SRV3Decoder{Words: true}, document: srv3Export, want: words},
+		{name: "json3", decoder: JSON3Decoder{}, document: json3Export, want: events},
+		{name: "json3 words", decoder: JSON3Decoder{Words: true}, document: json3Export, want: words},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decoder.Decode(strings.NewReader(tt.document))
+			if err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Decode() returned %d subtitles, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("subtitle %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestYouTubeText(t *testing.T) {
+	italic, bold := youtubePen{italic: true}, youtubePen{bold: true}
+
+	tests := []struct {
+		name     string
+		segments []youtubeSegment
+		want     string
+	}{
+		{"plain", []youtubeSegment{{text: "Hello"}, {text: " world"}}, "Hello world"},
+		{"pen change", []youtubeSegment{{text: "a ", pen: italic}, {text: "b", pen: bold}, {text: " c"}}, "<i>a </i><b>b</b> c"},
+		{"same pen joined", []youtubeSegment{{text: "a", pen: italic}, {text: " b", pen: italic}}, "<i>a b</i>"},
+		{"nested styling", []youtubeSegment{{text: "x", pen: youtubePen{bold: true, italic: true}}}, "<b><i>x</i></b>"},
+		{"trimmed lines", []youtubeSegment{{text: "\n  one \r\n two  \n"}}, "one\ntwo"},
+		{"only whitespace", []youtubeSegment{{text: "\n", pen: italic}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := youtubeText(tt.segments); got != tt.want {
+				t.Errorf("youtubeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYouTubeDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		decoder  Decoder
+		document string
+		wantErr  error
+	}{
+		{
+			name:     "srv3 bad time",
+			decoder:  SRV3Decoder{},
+			document: `<timedtext format="3"><body><p t="1s" d="100">Hi</p></body></timedtext>`,
+			wantErr:  ErrBadTime,
+		},
+		{
+			name:     "json3 negative time",
+			decoder:  JSON3Decoder{},
+			document: `{"events": [{"tStartMs": -5, "dDurationMs": 100, "segs": [{"utf8": "Hi"}]}]}`,
+			wantErr:  ErrBadTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.decoder.Decode(strings.NewReader(tt.document)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, decoder := range []Decoder{SRV3Decoder{}, JSON3Decoder{}} {
+		if _, err := decoder.Decode(strings.NewReader("<timedtext><body><p")); err == nil {
+			t.Errorf("%T.Decode() expected error for a truncated document, got nil", decoder)
+		}
+	}
+}